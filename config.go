@@ -29,6 +29,7 @@ type Config struct {
 	Root   string // Directory where board files are written to.
 	Serve  string // Address:Port to listen for HTTP connections on.
 	Header string // Client IP address header.
+	Secure bool   // Only send session cookies over HTTPS.
 
 	SaltData string // Long random string of text used when one-way hashing data. Must not change once set.
 	SaltPass string // Long random string of text used when two-way hashing data. Must not change once set.
@@ -42,6 +43,8 @@ type Config struct {
 
 	Template string // Custom template directory.
 
+	GeoIP string // Path to GeoIP country database (MaxMind DB format).
+
 	Uploads []string // Supported upload file types.
 
 	Import ImportConfig // Board import configuration.
@@ -116,30 +116,89 @@ func (db *Database) _upgrade(rootDir string, v int) error {
 	}
 	switch v {
 	case 5: // Add file MIME type to posts.
-		boards := db.AllBoards()
-		for _, b := range boards {
-			allThreads := db.AllThreads(b, false)
-			for _, threadInfo := range allThreads {
-				posts := db.AllPostsInThread(threadInfo[0], false)
-				for _, post := range posts {
-					if post.File != "" && !post.IsEmbed() {
-						if strings.HasSuffix(post.File, ".tgkr") {
-							post.FileMIME = "application/x-tegaki"
-						} else {
-							mimeInfo, err := mimetype.DetectFile(filepath.Join(rootDir, b.Dir, "src", post.File))
-							if err == nil {
-								post.FileMIME = mimeInfo.String()
-							}
-						}
-						if post.FileMIME != "" {
-							_, err = db.conn.Exec(context.Background(), "UPDATE post SET filemime = $1 WHERE id = $2", post.FileMIME, post.ID)
-							if err != nil {
-								return err
-							}
-						}
-					}
+		// Only columns present in version 5 are selected, as later versions
+		// add columns to the board and post tables.
+		rows, err := db.conn.Query(context.Background(), "SELECT post.id, post.file, COALESCE(post.filehash, ''), board.dir FROM post INNER JOIN board ON board.id = post.board WHERE post.file != ''")
+		if err != nil {
+			return err
+		}
+		type postFile struct {
+			post *Post
+			dir  string
+		}
+		var files []*postFile
+		for rows.Next() {
+			f := &postFile{post: &Post{}}
+			err = rows.Scan(&f.post.ID, &f.post.File, &f.post.FileHash, &f.dir)
+			if err != nil {
+				return err
+			}
+			files = append(files, f)
+		}
+		if rows.Err() != nil {
+			return rows.Err()
+		}
+		for _, f := range files {
+			post := f.post
+			if post.IsEmbed() {
+				continue
+			}
+			if strings.HasSuffix(post.File, ".tgkr") {
+				post.FileMIME = "application/x-tegaki"
+			} else {
+				mimeInfo, err := mimetype.DetectFile(filepath.Join(rootDir, f.dir, "src", post.File))
+				if err == nil {
+					post.FileMIME = mimeInfo.String()
 				}
 			}
+			if post.FileMIME != "" {
+				_, err = db.conn.Exec(context.Background(), "UPDATE post SET filemime = $1 WHERE id = $2", post.FileMIME, post.ID)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case 10: // Convert wildcard range bans to CIDR range bans.
+		// Only columns present in version 10 are selected, as later versions
+		// add columns to the ban table.
+		rows, err := db.conn.Query(context.Background(), "SELECT id, ip FROM ban WHERE ip LIKE 'r %'")
+		if err != nil {
+			return err
+		}
+		type rangeBan struct {
+			id int
+			ip string
+		}
+		var bans []*rangeBan
+		for rows.Next() {
+			b := &rangeBan{}
+			err = rows.Scan(&b.id, &b.ip)
+			if err != nil {
+				return err
+			}
+			bans = append(bans, b)
+		}
+		if rows.Err() != nil {
+			return rows.Err()
+		}
+		for _, b := range bans {
+			wildcard := strings.ReplaceAll(strings.ReplaceAll(b.ip[2:], `\.`, "."), ".*", "*")
+			prefix, ok := parseBanRange(wildcard)
+			if !ok {
+				continue
+			}
+			ip := "c " + prefix.String()
+			var existing int
+			err = db.conn.QueryRow(context.Background(), "SELECT COUNT(*) FROM ban WHERE ip = $1", ip).Scan(&existing)
+			if err != nil {
+				return err
+			} else if existing > 0 {
+				continue
+			}
+			_, err = db.conn.Exec(context.Background(), "UPDATE ban SET ip = $1, iprange = $2 WHERE id = $3", ip, prefix, b.id)
+			if err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -288,11 +347,11 @@ func (db *Database) newSessionKey() string {
 		}
 		sessionKey := base64.URLEncoding.EncodeToString(buf)
 
-		var numAccounts int
-		err = db.conn.QueryRow(context.Background(), "SELECT COUNT(*) FROM account WHERE session = $1", sessionKey).Scan(&numAccounts)
+		var numSessions int
+		err = db.conn.QueryRow(context.Background(), "SELECT COUNT(*) FROM session WHERE key = $1", sessionKey).Scan(&numSessions)
 		if err != nil {
-			log.Fatalf("failed to select number of accounts with session key: %s", err)
-		} else if numAccounts == 0 {
+			log.Fatalf("failed to select number of sessions with key: %s", err)
+		} else if numSessions == 0 {
 			return sessionKey
 		}
 	}
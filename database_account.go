@@ -2,6 +2,7 @@ package sriracha
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"strings"
 	"time"
@@ -10,13 +11,12 @@ import (
 )
 
 func (db *Database) addAccount(a *Account, password string) {
-	sessionKey := db.newSessionKey()
 	_, err := db.conn.Exec(context.Background(), "INSERT INTO account VALUES (DEFAULT, $1, $2, $3, 0, $4, $5)",
 		a.Username,
 		encryptPassword(password),
 		a.Role,
-		sessionKey,
 		a.Style,
+		a.CustomRole,
 	)
 	if err != nil {
 		log.Fatalf("failed to insert account: %s", err)
@@ -25,6 +25,62 @@ func (db *Database) addAccount(a *Account, password string) {
 	if err != nil || a.ID == 0 {
 		log.Fatalf("failed to select id of inserted account: %s", err)
 	}
+	db.updateAccountBoards(a)
+}
+
+func (db *Database) fetchAccountBoards(a *Account) {
+	a.Boards = nil
+
+	rows, err := db.conn.Query(context.Background(), "SELECT board FROM account_board WHERE account = $1", a.ID)
+	if err != nil {
+		log.Fatalf("failed to select account boards: %s", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			log.Fatalf("failed to select account boards: %s", err)
+		}
+		ids = append(ids, id)
+	}
+
+	for _, id := range ids {
+		board := db.BoardByID(id)
+		a.Boards = append(a.Boards, board)
+	}
+}
+
+// fetchAccountRole loads the permissions of the account's role.
+func (db *Database) fetchAccountRole(a *Account) {
+	a.Permissions = nil
+	a.RoleName = ""
+	a.Require2FA = false
+	if a.Role != RoleCustom {
+		a.Permissions = rolePermissions(a.Role)
+		a.Require2FA = srirachaServer.opt.Requires2FA(a.Role)
+		return
+	}
+	role := db.roleByID(a.CustomRole)
+	if role == nil {
+		return
+	}
+	a.Permissions = role.Permissions
+	a.RoleName = role.Name
+	a.Require2FA = role.Require2FA
+}
+
+func (db *Database) updateAccountBoards(a *Account) {
+	_, err := db.conn.Exec(context.Background(), "DELETE FROM account_board WHERE account = $1", a.ID)
+	if err != nil {
+		log.Fatalf("failed to update account boards: %s", err)
+	}
+	for _, board := range a.Boards {
+		_, err = db.conn.Exec(context.Background(), "INSERT INTO account_board VALUES ($1, $2)", a.ID, board.ID)
+		if err != nil {
+			log.Fatalf("failed to update account boards: %s", err)
+		}
+	}
 }
 
 func (db *Database) createSuperAdminAccount() {
@@ -39,18 +95,20 @@ func (db *Database) createSuperAdminAccount() {
 	if err != nil {
 		log.Fatalf("failed to select number of super-administrator accounts: %s", err)
 	} else if numAdmins > 0 {
-		sessionKey := db.newSessionKey()
-		_, err = db.conn.Exec(context.Background(), "UPDATE account SET password = $1, role = $2, session = $3 WHERE username = 'admin'",
+		_, err = db.conn.Exec(context.Background(), "UPDATE account SET password = $1, role = $2, mustchange = 1 WHERE username = 'admin'",
 			encryptPassword("admin"),
 			RoleSuperAdmin,
-			sessionKey,
 		)
 		if err != nil {
 			log.Fatalf("failed to insert account: %s", err)
 		}
+		_, err = db.conn.Exec(context.Background(), "DELETE FROM session WHERE account = (SELECT id FROM account WHERE username = 'admin')")
+		if err != nil {
+			log.Fatalf("failed to delete sessions: %s", err)
+		}
 		return
 	}
-	_, err = db.conn.Exec(context.Background(), "INSERT INTO account VALUES (DEFAULT, 'admin', $1, $2, 0, '')", encryptPassword("admin"), RoleSuperAdmin)
+	_, err = db.conn.Exec(context.Background(), "INSERT INTO account VALUES (DEFAULT, 'admin', $1, $2, 0, '', 0, '', '', 1)", encryptPassword("admin"), RoleSuperAdmin)
 	if err != nil {
 		log.Fatalf("failed to insert account: %s", err)
 	}
@@ -64,6 +122,8 @@ func (db *Database) accountByID(id int) *Account {
 	} else if err != nil {
 		log.Fatalf("failed to select account: %s", err)
 	}
+	db.fetchAccountBoards(a)
+	db.fetchAccountRole(a)
 	return a
 }
 
@@ -80,18 +140,32 @@ func (db *Database) accountByUsername(username string) *Account {
 	return a
 }
 
+// accountBySessionKey returns the account of an unexpired session. Expired
+// sessions are deleted.
 func (db *Database) accountBySessionKey(sessionKey string) *Account {
 	if strings.TrimSpace(sessionKey) == "" {
 		return nil
 	}
 
+	session := db.sessionByKey(sessionKey)
+	if session == nil {
+		return nil
+	} else if session.expired(&srirachaServer.opt, time.Now().Unix()) {
+		db.deleteSession(session.ID)
+		return nil
+	}
+
 	a := &Account{}
-	err := scanAccount(a, db.conn.QueryRow(context.Background(), "SELECT * FROM account WHERE session = $1 AND role != $2", sessionKey, RoleDisabled))
+	err := scanAccount(a, db.conn.QueryRow(context.Background(), "SELECT * FROM account WHERE id = $1 AND role != $2", session.AccountID, RoleDisabled))
 	if err == pgx.ErrNoRows {
 		return nil
 	} else if err != nil {
 		log.Fatalf("failed to select account: %s", err)
 	}
+	db.updateSessionLastUsed(session.ID)
+	a.Session = sessionKey
+	db.fetchAccountBoards(a)
+	db.fetchAccountRole(a)
 	return a
 }
 
@@ -109,6 +183,10 @@ func (db *Database) allAccounts() []*Account {
 		}
 		accounts = append(accounts, a)
 	}
+	for _, a := range accounts {
+		db.fetchAccountBoards(a)
+		db.fetchAccountRole(a)
+	}
 	return accounts
 }
 
@@ -116,18 +194,18 @@ func (db *Database) updateAccountUsername(a *Account) {
 	if a == nil || a.ID <= 0 {
 		log.Fatalf("invalid account")
 	}
-	sessionKey := db.newSessionKey()
-	_, err := db.conn.Exec(context.Background(), "UPDATE account SET username = $1, session = $2 WHERE id = $3", a.Username, sessionKey, a.ID)
+	_, err := db.conn.Exec(context.Background(), "UPDATE account SET username = $1 WHERE id = $2", a.Username, a.ID)
 	if err != nil {
 		log.Fatalf("failed to update account: %s", err)
 	}
+	db.deleteAccountSessions(a.ID, "")
 }
 
 func (db *Database) updateAccountRole(a *Account) {
 	if a == nil || a.ID <= 0 {
 		log.Fatalf("invalid account")
 	}
-	_, err := db.conn.Exec(context.Background(), "UPDATE account SET role = $1 WHERE id = $2", a.Role, a.ID)
+	_, err := db.conn.Exec(context.Background(), "UPDATE account SET role = $1, customrole = $2 WHERE id = $3", a.Role, a.CustomRole, a.ID)
 	if err != nil {
 		log.Fatalf("failed to update account: %s", err)
 	}
@@ -137,8 +215,22 @@ func (db *Database) updateAccountPassword(id int, password string) {
 	if id <= 0 {
 		log.Fatalf("invalid account ID %d", id)
 	}
-	sessionKey := db.newSessionKey()
-	_, err := db.conn.Exec(context.Background(), "UPDATE account SET password = $1, session = $2 WHERE id = $3", encryptPassword(password), sessionKey, id)
+	_, err := db.conn.Exec(context.Background(), "UPDATE account SET password = $1, mustchange = 0 WHERE id = $2", encryptPassword(password), id)
+	if err != nil {
+		log.Fatalf("failed to update account: %s", err)
+	}
+	db.deleteAccountSessions(id, "")
+}
+
+func (db *Database) updateAccountMustChange(id int, mustChange bool) {
+	if id <= 0 {
+		log.Fatalf("invalid account ID %d", id)
+	}
+	var value int
+	if mustChange {
+		value = 1
+	}
+	_, err := db.conn.Exec(context.Background(), "UPDATE account SET mustchange = $1 WHERE id = $2", value, id)
 	if err != nil {
 		log.Fatalf("failed to update account: %s", err)
 	}
@@ -164,7 +256,43 @@ func (db *Database) updateAccountStyle(id int, style string) {
 	}
 }
 
-func (db *Database) loginAccount(username string, password string) *Account {
+func (db *Database) updateAccountTOTP(id int, secret string, recovery string) {
+	if id <= 0 {
+		log.Fatalf("invalid account ID %d", id)
+	}
+	_, err := db.conn.Exec(context.Background(), "UPDATE account SET totp = $1, recovery = $2, totpstep = 0 WHERE id = $3", secret, recovery, id)
+	if err != nil {
+		log.Fatalf("failed to update account: %s", err)
+	}
+}
+
+// updateAccountTOTPStep records the time step of the last accepted TOTP code.
+// It returns false when a code of the same or a later time step was already
+// accepted.
+func (db *Database) updateAccountTOTPStep(id int, step int64) bool {
+	if id <= 0 {
+		log.Fatalf("invalid account ID %d", id)
+	}
+	result, err := db.conn.Exec(context.Background(), "UPDATE account SET totpstep = $1 WHERE id = $2 AND totpstep < $1", step, id)
+	if err != nil {
+		log.Fatalf("failed to update account: %s", err)
+	}
+	return result.RowsAffected() == 1
+}
+
+func (db *Database) updateAccountRecovery(id int, recovery string) {
+	if id <= 0 {
+		log.Fatalf("invalid account ID %d", id)
+	}
+	_, err := db.conn.Exec(context.Background(), "UPDATE account SET recovery = $1 WHERE id = $2", recovery, id)
+	if err != nil {
+		log.Fatalf("failed to update account: %s", err)
+	}
+}
+
+// accountByPassword returns the account matching the specified username and
+// password, without logging in.
+func (db *Database) accountByPassword(username string, password string) *Account {
 	a := &Account{}
 	err := scanAccount(a, db.conn.QueryRow(context.Background(), "SELECT * FROM account WHERE username = $1 AND role != $2", username, RoleDisabled))
 	if err == pgx.ErrNoRows {
@@ -174,22 +302,65 @@ func (db *Database) loginAccount(username string, password string) *Account {
 	} else if a.ID == 0 || !comparePassword(password, a.Password) {
 		return nil
 	}
-	a.Session = db.newSessionKey()
-	_, err = db.conn.Exec(context.Background(), "UPDATE account SET session = $1 WHERE id = $2", a.Session, a.ID)
-	if err != nil {
-		log.Fatalf("failed to update account: %s", err)
+	return a
+}
+
+// verifySecondFactor returns whether a code is a valid TOTP code or an unused
+// recovery code of the account. Each TOTP code may only be used once, and codes
+// older than the last accepted code are rejected. Recovery codes are removed
+// once used.
+func (db *Database) verifySecondFactor(a *Account, code string) bool {
+	if !a.TwoFactor() {
+		return true
+	} else if step, ok := verifyTOTP(a.TOTP, code, time.Now()); ok {
+		if step <= a.TOTPStep || !db.updateAccountTOTPStep(a.ID, step) {
+			return false
+		}
+		a.TOTPStep = step
+		return true
+	}
+	used, remaining := useRecoveryCode(a.Recovery, code)
+	if !used {
+		return false
 	}
+	a.Recovery = remaining
+	db.updateAccountRecovery(a.ID, a.Recovery)
+	db.log(a, nil, "Used two-factor recovery code", fmt.Sprintf("%d recovery codes remaining", a.RecoveryCodes()))
+	return true
+}
+
+// loginAccount returns the account matching the specified username, password
+// and, when two-factor authentication is enabled, TOTP or recovery code. A new
+// session is started for the account.
+func (db *Database) loginAccount(username string, password string, code string, userAgent string, ip string) *Account {
+	a := db.accountByPassword(username, password)
+	if a == nil || !db.verifySecondFactor(a, code) {
+		return nil
+	}
+	a.Session = db.addSession(a.ID, userAgent, ip)
+	db.fetchAccountBoards(a)
+	db.fetchAccountRole(a)
 	return a
 }
 
 func scanAccount(a *Account, row pgx.Row) error {
-	return row.Scan(
+	var mustChange int
+	err := row.Scan(
 		&a.ID,
 		&a.Username,
 		&a.Password,
 		&a.Role,
 		&a.LastActive,
-		&a.Session,
 		&a.Style,
+		&a.CustomRole,
+		&a.TOTP,
+		&a.Recovery,
+		&a.TOTPStep,
+		&mustChange,
 	)
+	if err != nil {
+		return err
+	}
+	a.MustChange = mustChange == 1
+	return nil
 }
@@ -0,0 +1,107 @@
+package sriracha
+
+import (
+	"context"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (db *Database) addAppeal(a *Appeal) {
+	err := db.conn.QueryRow(context.Background(), "INSERT INTO appeal VALUES (DEFAULT, $1, $2, $3, $4, $5, $6) RETURNING id",
+		a.Ban.ID,
+		a.IP,
+		a.Timestamp,
+		a.Message,
+		a.Status,
+		a.Response,
+	).Scan(&a.ID)
+	if err != nil || a.ID == 0 {
+		log.Fatalf("failed to insert appeal: %s", err)
+	}
+}
+
+func (db *Database) appealByID(id int) *Appeal {
+	a := &Appeal{}
+	banID, err := scanAppeal(a, db.conn.QueryRow(context.Background(), "SELECT * FROM appeal WHERE id = $1", id))
+	if err == pgx.ErrNoRows {
+		return nil
+	} else if err != nil {
+		log.Fatalf("failed to select appeal: %s", err)
+	}
+	a.Ban = db.banByID(banID)
+	return a
+}
+
+func (db *Database) appealByBan(b *Ban) *Appeal {
+	a := &Appeal{}
+	_, err := scanAppeal(a, db.conn.QueryRow(context.Background(), "SELECT * FROM appeal WHERE ban = $1", b.ID))
+	if err == pgx.ErrNoRows {
+		return nil
+	} else if err != nil {
+		log.Fatalf("failed to select appeal: %s", err)
+	}
+	a.Ban = b
+	return a
+}
+
+// recentAppeal returns whether an appeal was submitted by the specified IP
+// address after the specified time.
+func (db *Database) recentAppeal(ip string, since int64) bool {
+	var count int
+	err := db.conn.QueryRow(context.Background(), "SELECT COUNT(*) FROM appeal WHERE ip = $1 AND timestamp > $2", ip, since).Scan(&count)
+	if err != nil {
+		log.Fatalf("failed to select recent appeals: %s", err)
+	}
+	return count > 0
+}
+
+func (db *Database) pendingAppeals() []*Appeal {
+	rows, err := db.conn.Query(context.Background(), "SELECT * FROM appeal WHERE status = $1 ORDER BY timestamp ASC", AppealPending)
+	if err != nil {
+		log.Fatalf("failed to select pending appeals: %s", err)
+	}
+	var appeals []*Appeal
+	var banIDs []int
+	for rows.Next() {
+		a := &Appeal{}
+		banID, err := scanAppeal(a, rows)
+		if err != nil {
+			log.Fatalf("failed to select pending appeals: %s", err)
+		}
+		appeals = append(appeals, a)
+		banIDs = append(banIDs, banID)
+	}
+	for i, a := range appeals {
+		a.Ban = db.banByID(banIDs[i])
+	}
+	return appeals
+}
+
+func (db *Database) updateAppeal(a *Appeal) {
+	if a.ID <= 0 {
+		log.Fatalf("invalid appeal ID %d", a.ID)
+	}
+	_, err := db.conn.Exec(context.Background(), "UPDATE appeal SET status = $1, response = $2 WHERE id = $3",
+		a.Status,
+		a.Response,
+		a.ID,
+	)
+	if err != nil {
+		log.Fatalf("failed to update appeal: %s", err)
+	}
+}
+
+func scanAppeal(a *Appeal, row pgx.Row) (int, error) {
+	var banID int
+	err := row.Scan(
+		&a.ID,
+		&banID,
+		&a.IP,
+		&a.Timestamp,
+		&a.Message,
+		&a.Status,
+		&a.Response,
+	)
+	return banID, err
+}
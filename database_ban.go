@@ -3,17 +3,28 @@ package sriracha
 import (
 	"context"
 	"log"
+	"net/netip"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 )
 
 func (db *Database) addBan(b *Ban) {
-	_, err := db.conn.Exec(context.Background(), "INSERT INTO ban VALUES (DEFAULT, $1, $2, $3, $4)",
+	var ipRange *netip.Prefix
+	if b.Range != "" {
+		prefix, err := netip.ParsePrefix(b.Range)
+		if err != nil {
+			log.Fatalf("failed to parse ban range %s: %s", b.Range, err)
+		}
+		ipRange = &prefix
+	}
+	_, err := db.conn.Exec(context.Background(), "INSERT INTO ban VALUES (DEFAULT, $1, $2, $3, $4, $5, $6)",
 		b.IP,
 		time.Now().Unix(),
 		b.Expire,
 		b.Reason,
+		ipRange,
+		b.Type,
 	)
 	if err != nil {
 		log.Fatalf("failed to insert ban: %s", err)
@@ -22,6 +33,43 @@ func (db *Database) addBan(b *Ban) {
 	if err != nil || b.ID == 0 {
 		log.Fatalf("failed to select id of inserted ban: %s", err)
 	}
+	db.updateBanBoards(b)
+}
+
+func (db *Database) fetchBanBoards(b *Ban) {
+	b.Boards = nil
+
+	rows, err := db.conn.Query(context.Background(), "SELECT board FROM ban_board WHERE ban = $1", b.ID)
+	if err != nil {
+		log.Fatalf("failed to select ban boards: %s", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			log.Fatalf("failed to select ban boards: %s", err)
+		}
+		ids = append(ids, id)
+	}
+
+	for _, id := range ids {
+		board := db.BoardByID(id)
+		b.Boards = append(b.Boards, board)
+	}
+}
+
+func (db *Database) updateBanBoards(b *Ban) {
+	_, err := db.conn.Exec(context.Background(), "DELETE FROM ban_board WHERE ban = $1", b.ID)
+	if err != nil {
+		log.Fatalf("failed to update ban boards: %s", err)
+	}
+	for _, board := range b.Boards {
+		_, err = db.conn.Exec(context.Background(), "INSERT INTO ban_board VALUES ($1, $2)", b.ID, board.ID)
+		if err != nil {
+			log.Fatalf("failed to update ban boards: %s", err)
+		}
+	}
 }
 
 func (db *Database) banByID(id int) *Ban {
@@ -32,6 +80,7 @@ func (db *Database) banByID(id int) *Ban {
 	} else if err != nil {
 		log.Fatalf("failed to select ban: %s", err)
 	}
+	db.fetchBanBoards(b)
 	return b
 }
 
@@ -43,13 +92,14 @@ func (db *Database) banByIP(ip string) *Ban {
 	} else if err != nil {
 		log.Fatalf("failed to select ban: %s", err)
 	}
+	db.fetchBanBoards(b)
 	return b
 }
 
-func (db *Database) allBans(rangeOnly bool) []*Ban {
+func (db *Database) allBans(patternOnly bool) []*Ban {
 	var extra string
-	if rangeOnly {
-		extra = " WHERE ip LIKE 'r %'"
+	if patternOnly {
+		extra = " WHERE ip LIKE 'r %' OR ip LIKE 'c %' OR ip LIKE 'n %'"
 	}
 	rows, err := db.conn.Query(context.Background(), "SELECT * FROM ban"+extra+" ORDER BY timestamp DESC")
 	if err != nil {
@@ -64,6 +114,9 @@ func (db *Database) allBans(rangeOnly bool) []*Ban {
 		}
 		bans = append(bans, b)
 	}
+	for _, b := range bans {
+		db.fetchBanBoards(b)
+	}
 	return bans
 }
 
@@ -71,14 +124,16 @@ func (db *Database) updateBan(b *Ban) {
 	if b.ID <= 0 {
 		log.Fatalf("invalid ban ID %d", b.ID)
 	}
-	_, err := db.conn.Exec(context.Background(), "UPDATE ban SET expire = $1, reason = $2 WHERE id = $3",
+	_, err := db.conn.Exec(context.Background(), "UPDATE ban SET expire = $1, reason = $2, type = $3 WHERE id = $4",
 		b.Expire,
 		b.Reason,
+		b.Type,
 		b.ID,
 	)
 	if err != nil {
 		log.Fatalf("failed to update ban: %s", err)
 	}
+	db.updateBanBoards(b)
 }
 
 func (db *Database) deleteExpiredBans() int {
@@ -101,11 +156,21 @@ func (db *Database) deleteBan(id int) {
 }
 
 func scanBan(b *Ban, row pgx.Row) error {
-	return row.Scan(
+	var ipRange *netip.Prefix
+	err := row.Scan(
 		&b.ID,
 		&b.IP,
 		&b.Timestamp,
 		&b.Expire,
 		&b.Reason,
+		&ipRange,
+		&b.Type,
 	)
+	if err != nil {
+		return err
+	}
+	if ipRange != nil {
+		b.Range = ipRange.String()
+	}
+	return nil
 }
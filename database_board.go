@@ -17,7 +17,15 @@ func (db *Database) addBoard(b *Board) {
 	if b.Oekaki {
 		oekaki = 1
 	}
-	_, err := db.conn.Exec(context.Background(), "INSERT INTO board VALUES (DEFAULT, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33)",
+	var flags int
+	if b.Flags {
+		flags = 1
+	}
+	var captcha int
+	if b.CAPTCHA {
+		captcha = 1
+	}
+	_, err := db.conn.Exec(context.Background(), "INSERT INTO board VALUES (DEFAULT, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36, $37, $38)",
 		b.Dir,
 		b.Name,
 		b.Description,
@@ -51,6 +59,11 @@ func (db *Database) addBoard(b *Board) {
 		b.MaxReplies,
 		oekaki,
 		strings.Join(b.Rules, "|||"),
+		flags,
+		b.PostsPerPage,
+		captcha,
+		b.CAPTCHAProvider,
+		b.ReportThreshold,
 	)
 	if err != nil {
 		log.Fatalf("failed to insert board: %s", err)
@@ -190,7 +203,15 @@ func (db *Database) updateBoard(b *Board) {
 	if b.Oekaki {
 		oekaki = 1
 	}
-	_, err := db.conn.Exec(context.Background(), "UPDATE board SET dir = $1, name = $2, description = $3, type = $4, lock = $5, approval = $6, reports = $7, style = $8, locale = $9, delay = $10, minname = $11, maxname = $12, minemail = $13, maxemail = $14, minsubject = $15, maxsubject = $16, minmessage = $17, maxmessage = $18, minsizethread = $19, maxsizethread = $20, minsizereply = $21, maxsizereply = $22, thumbwidth = $23, thumbheight = $24, defaultname = $25, wordbreak = $26, truncate = $27, threads = $28, replies = $29, maxthreads = $30, maxreplies = $31, oekaki = $32, rules = $33 WHERE id = $34",
+	var flags int
+	if b.Flags {
+		flags = 1
+	}
+	var captcha int
+	if b.CAPTCHA {
+		captcha = 1
+	}
+	_, err := db.conn.Exec(context.Background(), "UPDATE board SET dir = $1, name = $2, description = $3, type = $4, lock = $5, approval = $6, reports = $7, style = $8, locale = $9, delay = $10, minname = $11, maxname = $12, minemail = $13, maxemail = $14, minsubject = $15, maxsubject = $16, minmessage = $17, maxmessage = $18, minsizethread = $19, maxsizethread = $20, minsizereply = $21, maxsizereply = $22, thumbwidth = $23, thumbheight = $24, defaultname = $25, wordbreak = $26, truncate = $27, threads = $28, replies = $29, maxthreads = $30, maxreplies = $31, oekaki = $32, rules = $33, flags = $34, postsperpage = $35, captcha = $36, captchaprovider = $37, reportthreshold = $38 WHERE id = $39",
 		b.Dir,
 		b.Name,
 		b.Description,
@@ -224,6 +245,11 @@ func (db *Database) updateBoard(b *Board) {
 		b.MaxReplies,
 		oekaki,
 		strings.Join(b.Rules, "|||"),
+		flags,
+		b.PostsPerPage,
+		captcha,
+		b.CAPTCHAProvider,
+		b.ReportThreshold,
 		b.ID,
 	)
 	if err != nil {
@@ -257,6 +283,8 @@ func scanBoard(b *Board, row pgx.Row) error {
 	var reports int
 	var oekaki int
 	var rules string
+	var flags int
+	var captcha int
 	err := row.Scan(
 		&b.ID,
 		&b.Dir,
@@ -292,12 +320,19 @@ func scanBoard(b *Board, row pgx.Row) error {
 		&b.MaxReplies,
 		&oekaki,
 		&rules,
+		&flags,
+		&b.PostsPerPage,
+		&captcha,
+		&b.CAPTCHAProvider,
+		&b.ReportThreshold,
 	)
 	if err != nil {
 		return err
 	}
 	b.Reports = reports == 1
 	b.Oekaki = oekaki == 1
+	b.Flags = flags == 1
+	b.CAPTCHA = captcha == 1
 	if rules != "" {
 		b.Rules = strings.Split(rules, "|||")
 	}
@@ -3,14 +3,19 @@ package sriracha
 import (
 	"context"
 	"log"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 )
 
 func (db *Database) addKeyword(k *Keyword) {
-	_, err := db.conn.Exec(context.Background(), "INSERT INTO keyword VALUES (DEFAULT, $1, $2)",
+	_, err := db.conn.Exec(context.Background(), "INSERT INTO keyword VALUES (DEFAULT, $1, $2, $3, $4, $5, $6, 0)",
 		k.Text,
 		k.Action,
+		strings.Join(k.Fields, ","),
+		k.Replace,
+		k.BanExpire,
+		k.BanReason,
 	)
 	if err != nil {
 		log.Fatalf("failed to insert keyword: %s", err)
@@ -108,9 +113,13 @@ func (db *Database) updateKeyword(k *Keyword) {
 	if k.ID <= 0 {
 		log.Fatalf("invalid keyword ID %d", k.ID)
 	}
-	_, err := db.conn.Exec(context.Background(), "UPDATE keyword SET text = $1, action = $2 WHERE id = $3",
+	_, err := db.conn.Exec(context.Background(), "UPDATE keyword SET text = $1, action = $2, fields = $3, replace = $4, banexpire = $5, banreason = $6 WHERE id = $7",
 		k.Text,
 		k.Action,
+		strings.Join(k.Fields, ","),
+		k.Replace,
+		k.BanExpire,
+		k.BanReason,
 		k.ID,
 	)
 	if err != nil {
@@ -119,6 +128,13 @@ func (db *Database) updateKeyword(k *Keyword) {
 	db.updateKeywordBoards(k)
 }
 
+func (db *Database) addKeywordHit(id int) {
+	_, err := db.conn.Exec(context.Background(), "UPDATE keyword SET hits = hits + 1 WHERE id = $1", id)
+	if err != nil {
+		log.Fatalf("failed to update keyword hits: %s", err)
+	}
+}
+
 func (db *Database) deleteKeyword(id int) {
 	if id == 0 {
 		return
@@ -130,9 +146,23 @@ func (db *Database) deleteKeyword(id int) {
 }
 
 func scanKeyword(k *Keyword, row pgx.Row) error {
-	return row.Scan(
+	var fields string
+	err := row.Scan(
 		&k.ID,
 		&k.Text,
 		&k.Action,
+		&fields,
+		&k.Replace,
+		&k.BanExpire,
+		&k.BanReason,
+		&k.Hits,
 	)
+	if err != nil {
+		return err
+	}
+	k.Fields = nil
+	if fields != "" {
+		k.Fields = strings.Split(fields, ",")
+	}
+	return nil
 }
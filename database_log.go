@@ -2,7 +2,9 @@ package sriracha
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -16,17 +18,21 @@ func (db *Database) addLog(l *Log) {
 	var accountID *int
 	if l.Account != nil {
 		accountID = &l.Account.ID
+		if l.Account.APIToken != nil {
+			l.Message += fmt.Sprintf(" (API token %s)", l.Account.APIToken.Name)
+		}
 	}
 	var boardID *int
 	if l.Board != nil {
 		boardID = &l.Board.ID
 	}
-	_, err := db.conn.Exec(context.Background(), "INSERT INTO log VALUES (DEFAULT, $1, $2, $3, $4, $5)",
+	_, err := db.conn.Exec(context.Background(), "INSERT INTO log VALUES (DEFAULT, $1, $2, $3, $4, $5, $6)",
 		accountID,
 		boardID,
 		time.Now().Unix(),
 		l.Message,
 		l.Changes,
+		strings.ToLower(l.Plugin),
 	)
 	if err != nil {
 		log.Fatalf("failed to insert log: %s", err)
@@ -39,12 +45,20 @@ func (db *Database) log(account *Account, board *Board, message string, changes
 		Board:   board,
 		Message: message,
 		Changes: changes,
+		Plugin:  db.plugin,
 	})
 }
 
-func (db *Database) logCount() int {
+// Log records an action in the management log. Actions logged by plugins are
+// attributed to the plugin. The account and board may be nil.
+func (db *Database) Log(account *Account, board *Board, message string, changes string) {
+	db.log(account, board, message, changes)
+}
+
+func (db *Database) logCount(a *Account, q *logQuery) int {
+	where, args := q.where(a)
 	var count int
-	err := db.conn.QueryRow(context.Background(), "SELECT COUNT(*) FROM log").Scan(&count)
+	err := db.conn.QueryRow(context.Background(), "SELECT COUNT(*) FROM log"+where, args...).Scan(&count)
 	if err == pgx.ErrNoRows {
 		return 0
 	} else if err != nil {
@@ -53,9 +67,21 @@ func (db *Database) logCount() int {
 	return count
 }
 
-func (db *Database) logsByPage(page int) []*Log {
+func (db *Database) logsByPage(a *Account, q *logQuery, page int) []*Log {
+	where, args := q.where(a)
 	offset := page * logPageSize
-	rows, err := db.conn.Query(context.Background(), "SELECT * FROM log ORDER BY id DESC LIMIT $1 OFFSET $2", logPageSize, offset)
+	args = append(args, logPageSize, offset)
+	return db.selectLogs(fmt.Sprintf("SELECT * FROM log%s ORDER BY id DESC LIMIT $%d OFFSET $%d", where, len(args)-1, len(args)), args...)
+}
+
+// allLogs returns all logs matching the query.
+func (db *Database) allLogs(a *Account, q *logQuery) []*Log {
+	where, args := q.where(a)
+	return db.selectLogs("SELECT * FROM log"+where+" ORDER BY id DESC", args...)
+}
+
+func (db *Database) selectLogs(query string, args ...interface{}) []*Log {
+	rows, err := db.conn.Query(context.Background(), query, args...)
 	if err != nil {
 		log.Fatalf("failed to select all logs: %s", err)
 	}
@@ -66,7 +92,7 @@ func (db *Database) logsByPage(page int) []*Log {
 		l := &Log{}
 		var boardID *int
 		var accountID *int
-		err := rows.Scan(&l.ID, &accountID, &boardID, &l.Timestamp, &l.Message, &l.Changes)
+		err := rows.Scan(&l.ID, &accountID, &boardID, &l.Timestamp, &l.Message, &l.Changes, &l.Plugin)
 		if err != nil {
 			log.Fatalf("failed to select all logs: %s", err)
 		}
@@ -82,14 +108,22 @@ func (db *Database) logsByPage(page int) []*Log {
 			boardIDs = append(boardIDs, *boardID)
 		}
 	}
+	accounts := make(map[int]*Account)
+	boards := make(map[int]*Board)
 	for i, l := range logs {
 		accountID := accountIDs[i]
 		boardID := boardIDs[i]
 		if accountID > 0 {
-			l.Account = db.accountByID(accountID)
+			if _, ok := accounts[accountID]; !ok {
+				accounts[accountID] = db.accountByID(accountID)
+			}
+			l.Account = accounts[accountID]
 		}
 		if boardID > 0 {
-			l.Board = db.BoardByID(boardID)
+			if _, ok := boards[boardID]; !ok {
+				boards[boardID] = db.BoardByID(boardID)
+			}
+			l.Board = boards[boardID]
 		}
 	}
 	return logs
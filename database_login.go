@@ -0,0 +1,46 @@
+package sriracha
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// loginFailures returns the number of recent failed login attempts and the
+// time of the last failed attempt.
+func (db *Database) loginFailures(key string) (int, int64) {
+	var failures int
+	var lastFailure int64
+	err := db.conn.QueryRow(context.Background(), "SELECT failures, lastfailure FROM login_failure WHERE key = $1", key).Scan(&failures, &lastFailure)
+	if err == pgx.ErrNoRows {
+		return 0, 0
+	} else if err != nil {
+		log.Fatalf("failed to select login failures: %s", err)
+	}
+	return failures, lastFailure
+}
+
+// addLoginFailure records a failed login attempt and returns the number of
+// recent failed attempts. Failures older than the lockout duration are removed.
+func (db *Database) addLoginFailure(key string) int {
+	now := time.Now().Unix()
+	_, err := db.conn.Exec(context.Background(), "DELETE FROM login_failure WHERE lastfailure <= $1", now-loginLockoutDuration)
+	if err != nil {
+		log.Fatalf("failed to delete login failures: %s", err)
+	}
+	var failures int
+	err = db.conn.QueryRow(context.Background(), "INSERT INTO login_failure VALUES ($1, 1, $2) ON CONFLICT (key) DO UPDATE SET failures = login_failure.failures + 1, lastfailure = $3 RETURNING failures", key, now, now).Scan(&failures)
+	if err != nil {
+		log.Fatalf("failed to insert login failure: %s", err)
+	}
+	return failures
+}
+
+func (db *Database) deleteLoginFailures(key string) {
+	_, err := db.conn.Exec(context.Background(), "DELETE FROM login_failure WHERE key = $1", key)
+	if err != nil {
+		log.Fatalf("failed to delete login failures: %s", err)
+	}
+}
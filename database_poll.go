@@ -0,0 +1,111 @@
+package sriracha
+
+import (
+	"context"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (db *Database) addPoll(p *Poll) {
+	var multiple int
+	if p.Multiple {
+		multiple = 1
+	}
+	err := db.conn.QueryRow(context.Background(), "INSERT INTO poll VALUES (DEFAULT, $1, $2, $3) RETURNING id",
+		p.Post,
+		multiple,
+		p.Expire,
+	).Scan(&p.ID)
+	if err != nil || p.ID == 0 {
+		log.Fatalf("failed to insert poll: %s", err)
+	}
+	for _, o := range p.Options {
+		o.Poll = p.ID
+		err := db.conn.QueryRow(context.Background(), "INSERT INTO poll_option VALUES (DEFAULT, $1, $2) RETURNING id",
+			o.Poll,
+			o.Text,
+		).Scan(&o.ID)
+		if err != nil || o.ID == 0 {
+			log.Fatalf("failed to insert poll option: %s", err)
+		}
+	}
+}
+
+func (db *Database) pollByID(id int) *Poll {
+	p := &Poll{}
+	err := scanPoll(p, db.conn.QueryRow(context.Background(), "SELECT * FROM poll WHERE id = $1", id))
+	if err == pgx.ErrNoRows {
+		return nil
+	} else if err != nil {
+		log.Fatalf("failed to select poll: %s", err)
+	}
+	db.setPollAttributes(p)
+	return p
+}
+
+func (db *Database) pollByPost(postID int) *Poll {
+	p := &Poll{}
+	err := scanPoll(p, db.conn.QueryRow(context.Background(), "SELECT * FROM poll WHERE post = $1", postID))
+	if err == pgx.ErrNoRows {
+		return nil
+	} else if err != nil {
+		log.Fatalf("failed to select poll: %s", err)
+	}
+	db.setPollAttributes(p)
+	return p
+}
+
+func (db *Database) setPollAttributes(p *Poll) {
+	rows, err := db.conn.Query(context.Background(), "SELECT poll_option.*, COUNT(poll_vote.ip) FROM poll_option LEFT OUTER JOIN poll_vote ON poll_vote.choice = poll_option.id WHERE poll_option.poll = $1 GROUP BY poll_option.id ORDER BY poll_option.id ASC", p.ID)
+	if err != nil {
+		log.Fatalf("failed to select poll options: %s", err)
+	}
+	p.Options = nil
+	for rows.Next() {
+		o := &PollOption{}
+		err := rows.Scan(&o.ID, &o.Poll, &o.Text, &o.Votes)
+		if err != nil {
+			log.Fatalf("failed to select poll options: %s", err)
+		}
+		p.Options = append(p.Options, o)
+	}
+
+	err = db.conn.QueryRow(context.Background(), "SELECT COUNT(DISTINCT ip) FROM poll_vote WHERE poll = $1", p.ID).Scan(&p.Voters)
+	if err != nil {
+		log.Fatalf("failed to select poll voters: %s", err)
+	}
+}
+
+func (db *Database) pollVoted(pollID int, ip string) bool {
+	var count int
+	err := db.conn.QueryRow(context.Background(), "SELECT COUNT(*) FROM poll_vote WHERE poll = $1 AND ip = $2", pollID, ip).Scan(&count)
+	if err == pgx.ErrNoRows {
+		return false
+	} else if err != nil {
+		log.Fatalf("failed to select poll vote count: %s", err)
+	}
+	return count > 0
+}
+
+func (db *Database) addPollVote(pollID int, optionID int, ip string, timestamp int64) {
+	_, err := db.conn.Exec(context.Background(), "INSERT INTO poll_vote VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING", pollID, optionID, ip, timestamp)
+	if err != nil {
+		log.Fatalf("failed to insert poll vote: %s", err)
+	}
+}
+
+func scanPoll(p *Poll, row pgx.Row) error {
+	var multiple int
+	err := row.Scan(
+		&p.ID,
+		&p.Post,
+		&multiple,
+		&p.Expire,
+	)
+	if err != nil {
+		return err
+	}
+	p.Multiple = multiple == 1
+	return nil
+}
@@ -26,7 +26,15 @@ func (db *Database) addPost(p *Post) {
 	if p.Locked {
 		locked = 1
 	}
-	err := db.conn.QueryRow(context.Background(), "INSERT INTO post VALUES (DEFAULT, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25) RETURNING id",
+	var autosage int
+	if p.Autosage {
+		autosage = 1
+	}
+	var reportHidden int
+	if p.ReportHidden {
+		reportHidden = 1
+	}
+	err := db.conn.QueryRow(context.Background(), "INSERT INTO post VALUES (DEFAULT, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31) RETURNING id",
 		parent,
 		p.Board.ID,
 		p.Timestamp,
@@ -52,6 +60,12 @@ func (db *Database) addPost(p *Post) {
 		stickied,
 		locked,
 		p.FileMIME,
+		p.Country,
+		p.Cycle,
+		autosage,
+		p.ReplyLimit,
+		p.BanMessage,
+		reportHidden,
 	).Scan(&p.ID)
 	if err != nil || p.ID == 0 {
 		log.Fatalf("failed to insert post: %s", err)
@@ -107,6 +121,28 @@ func (db *Database) trimThreads(board *Board) []*Post {
 	return posts
 }
 
+// trimReplies returns the oldest replies in a cyclical thread which exceed its reply limit.
+func (db *Database) trimReplies(thread *Post) []*Post {
+	if thread.Cycle == 0 {
+		return nil
+	}
+	rows, err := db.conn.Query(context.Background(), "SELECT *, 0 as replies FROM post WHERE parent = $1 ORDER BY id DESC OFFSET $2", thread.ID, thread.Cycle)
+	if err != nil {
+		log.Fatalf("failed to select trim replies: %s", err)
+	}
+	var posts []*Post
+	for rows.Next() {
+		p := &Post{}
+		_, err := scanPost(p, rows)
+		if err != nil {
+			log.Fatal(err)
+		}
+		p.Board = thread.Board
+		posts = append(posts, p)
+	}
+	return posts
+}
+
 func (db *Database) AllPostsInThread(postID int, moderated bool) []*Post {
 	var extra string
 	if moderated {
@@ -252,6 +288,18 @@ func (db *Database) replyCount(threadID int) int {
 	return count
 }
 
+// postIndex returns the position of a post within its thread.
+func (db *Database) postIndex(p *Post) int {
+	var index int
+	err := db.conn.QueryRow(context.Background(), "SELECT COUNT(*) FROM post WHERE (id = $1 OR parent = $1) AND id < $2 AND moderated > 0", p.Thread(), p.ID).Scan(&index)
+	if err == pgx.ErrNoRows {
+		return 0
+	} else if err != nil {
+		log.Fatalf("failed to select post index: %s", err)
+	}
+	return index
+}
+
 func (db *Database) bumpThread(threadID int, timestamp int64) {
 	_, err := db.conn.Exec(context.Background(), "UPDATE post SET bumped = $1 WHERE id = $2 AND bumped < $1", timestamp, threadID)
 	if err != nil {
@@ -260,12 +308,20 @@ func (db *Database) bumpThread(threadID int, timestamp int64) {
 }
 
 func (db *Database) moderatePost(postID int, moderated PostModerated) {
-	_, err := db.conn.Exec(context.Background(), "UPDATE post SET moderated = $1 WHERE id = $2", moderated, postID)
+	_, err := db.conn.Exec(context.Background(), "UPDATE post SET moderated = $1, reporthidden = 0 WHERE id = $2", moderated, postID)
 	if err != nil {
 		log.Fatalf("failed to moderate post: %s", err)
 	}
 }
 
+// reportHidePost hides a post which reached the report threshold of its board.
+func (db *Database) reportHidePost(postID int) {
+	_, err := db.conn.Exec(context.Background(), "UPDATE post SET moderated = $1, reporthidden = 1 WHERE id = $2", ModeratedHidden, postID)
+	if err != nil {
+		log.Fatalf("failed to hide reported post: %s", err)
+	}
+}
+
 func (db *Database) stickyPost(postID int, sticky bool) {
 	var stickied int
 	if sticky {
@@ -288,6 +344,24 @@ func (db *Database) lockPost(postID int, lock bool) {
 	}
 }
 
+func (db *Database) updateThreadOptions(p *Post) {
+	var autosage int
+	if p.Autosage {
+		autosage = 1
+	}
+	_, err := db.conn.Exec(context.Background(), "UPDATE post SET cycle = $1, autosage = $2, replylimit = $3 WHERE id = $4", p.Cycle, autosage, p.ReplyLimit, p.ID)
+	if err != nil {
+		log.Fatalf("failed to update thread options: %s", err)
+	}
+}
+
+func (db *Database) updatePostBanMessage(postID int, message string) {
+	_, err := db.conn.Exec(context.Background(), "UPDATE post SET banmessage = $1 WHERE id = $2", message, postID)
+	if err != nil {
+		log.Fatalf("failed to update post ban message: %s", err)
+	}
+}
+
 func (db *Database) updatePostMessage(postID int, message string) {
 	_, err := db.conn.Exec(context.Background(), "UPDATE post SET message = $1 WHERE id = $2", message, postID)
 	if err != nil {
@@ -308,11 +382,13 @@ func (db *Database) deletePost(postID int) {
 
 func scanPost(p *Post, row pgx.Row) (int, error) {
 	var (
-		parentID *int
-		boardID  int
-		fileHash *string
-		stickied int
-		locked   int
+		parentID     *int
+		boardID      int
+		fileHash     *string
+		stickied     int
+		locked       int
+		autosage     int
+		reportHidden int
 	)
 	err := row.Scan(
 		&p.ID,
@@ -341,6 +417,12 @@ func scanPost(p *Post, row pgx.Row) (int, error) {
 		&stickied,
 		&locked,
 		&p.FileMIME,
+		&p.Country,
+		&p.Cycle,
+		&autosage,
+		&p.ReplyLimit,
+		&p.BanMessage,
+		&reportHidden,
 		&p.Replies,
 	)
 	if err != nil {
@@ -354,5 +436,7 @@ func scanPost(p *Post, row pgx.Row) (int, error) {
 	}
 	p.Stickied = stickied == 1
 	p.Locked = locked == 1
+	p.Autosage = autosage == 1
+	p.ReportHidden = reportHidden == 1
 	return boardID, nil
 }
@@ -4,19 +4,19 @@ import (
 	"context"
 	"fmt"
 	"log"
-
-	"github.com/jackc/pgx/v5"
 )
 
 func (db *Database) addReport(r *Report) {
 	if r.Board == nil || r.Post == nil {
 		return
 	}
-	_, err := db.conn.Exec(context.Background(), "INSERT INTO report VALUES (DEFAULT, $1, $2, $3, $4) ON CONFLICT DO NOTHING",
+	_, err := db.conn.Exec(context.Background(), "INSERT INTO report VALUES (DEFAULT, $1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING",
 		r.Board.ID,
 		r.Post.ID,
 		r.Timestamp,
 		r.IP,
+		r.Category,
+		r.Comment,
 	)
 	if err != nil {
 		log.Fatalf("failed to insert report: %s", err)
@@ -51,21 +51,32 @@ func (db *Database) allReports() []*Report {
 		r := &Report{}
 		r.Board = db.BoardByID(boardID)
 		r.Post = db.PostByID(postID)
-		r.count = db.numReports(r.Post)
+		r.reports = db.postReports(r.Post)
+		r.count = len(r.reports)
 		reports[i] = r
 	}
 	return reports
 }
 
-func (db *Database) numReports(p *Post) int {
-	var count int
-	err := db.conn.QueryRow(context.Background(), "SELECT COUNT(*) FROM report WHERE board = $1 AND post = $2", p.Board.ID, p.ID).Scan(&count)
-	if err == pgx.ErrNoRows {
-		return 0
-	} else if err != nil {
-		log.Fatalf("failed to select report count: %s", err)
+// postReports returns the individual reports of a post, oldest first.
+func (db *Database) postReports(p *Post) []*Report {
+	rows, err := db.conn.Query(context.Background(), "SELECT * FROM report WHERE board = $1 AND post = $2 ORDER BY id ASC", p.Board.ID, p.ID)
+	if err != nil {
+		log.Fatalf("failed to select reports: %s", err)
+	}
+	var reports []*Report
+	for rows.Next() {
+		r := &Report{}
+		var boardID, postID int
+		err = rows.Scan(&r.ID, &boardID, &postID, &r.Timestamp, &r.IP, &r.Category, &r.Comment)
+		if err != nil {
+			log.Fatalf("failed to select reports: %s", err)
+		}
+		r.Board = p.Board
+		r.Post = p
+		reports = append(reports, r)
 	}
-	return count
+	return reports
 }
 
 func (db *Database) deleteReports(p *Post) {
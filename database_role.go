@@ -0,0 +1,131 @@
+package sriracha
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (db *Database) addRole(r *Role) {
+	var require2FA int
+	if r.Require2FA {
+		require2FA = 1
+	}
+	err := db.conn.QueryRow(context.Background(), "INSERT INTO role VALUES (DEFAULT, $1, $2, $3) RETURNING id",
+		r.Name,
+		joinPermissions(r.Permissions),
+		require2FA,
+	).Scan(&r.ID)
+	if err != nil || r.ID == 0 {
+		log.Fatalf("failed to insert role: %s", err)
+	}
+}
+
+func (db *Database) roleByID(id int) *Role {
+	r := &Role{}
+	err := scanRole(r, db.conn.QueryRow(context.Background(), "SELECT * FROM role WHERE id = $1", id))
+	if err == pgx.ErrNoRows {
+		return nil
+	} else if err != nil {
+		log.Fatalf("failed to select role: %s", err)
+	}
+	return r
+}
+
+func (db *Database) roleByName(name string) *Role {
+	r := &Role{}
+	err := scanRole(r, db.conn.QueryRow(context.Background(), "SELECT * FROM role WHERE name = $1", name))
+	if err == pgx.ErrNoRows {
+		return nil
+	} else if err != nil {
+		log.Fatalf("failed to select role: %s", err)
+	}
+	return r
+}
+
+func (db *Database) allRoles() []*Role {
+	rows, err := db.conn.Query(context.Background(), "SELECT * FROM role ORDER BY name ASC")
+	if err != nil {
+		log.Fatalf("failed to select roles: %s", err)
+	}
+	var roles []*Role
+	for rows.Next() {
+		r := &Role{}
+		err = scanRole(r, rows)
+		if err != nil {
+			log.Fatalf("failed to select roles: %s", err)
+		}
+		roles = append(roles, r)
+	}
+	return roles
+}
+
+func (db *Database) roleAccountCount(id int) int {
+	var count int
+	err := db.conn.QueryRow(context.Background(), "SELECT COUNT(*) FROM account WHERE role = $1 AND customrole = $2", RoleCustom, id).Scan(&count)
+	if err != nil {
+		log.Fatalf("failed to select role account count: %s", err)
+	}
+	return count
+}
+
+func (db *Database) updateRole(r *Role) {
+	if r.ID <= 0 {
+		log.Fatalf("invalid role ID %d", r.ID)
+	}
+	var require2FA int
+	if r.Require2FA {
+		require2FA = 1
+	}
+	_, err := db.conn.Exec(context.Background(), "UPDATE role SET name = $1, permissions = $2, require2fa = $3 WHERE id = $4",
+		r.Name,
+		joinPermissions(r.Permissions),
+		require2FA,
+		r.ID,
+	)
+	if err != nil {
+		log.Fatalf("failed to update role: %s", err)
+	}
+}
+
+func (db *Database) deleteRole(id int) {
+	if id <= 0 {
+		log.Fatalf("invalid role ID %d", id)
+	}
+	_, err := db.conn.Exec(context.Background(), "DELETE FROM role WHERE id = $1", id)
+	if err != nil {
+		log.Fatalf("failed to delete role: %s", err)
+	}
+}
+
+func joinPermissions(permissions []Permission) string {
+	values := make([]string, len(permissions))
+	for i, p := range permissions {
+		values[i] = string(p)
+	}
+	return strings.Join(values, ",")
+}
+
+func scanRole(r *Role, row pgx.Row) error {
+	var permissions string
+	var require2FA int
+	err := row.Scan(
+		&r.ID,
+		&r.Name,
+		&permissions,
+		&require2FA,
+	)
+	if err != nil {
+		return err
+	}
+	r.Require2FA = require2FA == 1
+	r.Permissions = nil
+	if permissions != "" {
+		for _, p := range strings.Split(permissions, ",") {
+			r.Permissions = append(r.Permissions, Permission(p))
+		}
+	}
+	return nil
+}
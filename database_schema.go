@@ -7,18 +7,25 @@ var dbSchema = []string{ // Version 1.
 	password text NOT NULL,
 	role integer NOT NULL,
 	lastactive bigint NOT NULL,
-	session varchar(64) NOT NULL
+	session varchar(64) NOT NULL -- v20: dropped
 	-- v2: style varchar(64) NOT NULL DEFAULT ''
+	-- v18: customrole integer NOT NULL DEFAULT 0
+	-- v19: totp varchar(64) NOT NULL DEFAULT ''
+	-- v19: recovery text NOT NULL DEFAULT ''
+	-- v19: totpstep bigint NOT NULL DEFAULT 0
+	-- v21: mustchange smallint NOT NULL DEFAULT 0
 );
 CREATE UNIQUE INDEX ON account (username);
 CREATE UNIQUE INDEX ON account (session);
 
 CREATE TABLE ban (
 	id serial PRIMARY KEY,
-	ip varchar(64) NOT NULL,
+	ip varchar(64) NOT NULL, -- v14: ip text NOT NULL
 	timestamp bigint NOT NULL,
 	expire bigint NOT NULL,
 	reason text NOT NULL
+	-- v10: iprange cidr NULL
+	-- v11: type smallint NOT NULL DEFAULT 0
 );
 CREATE UNIQUE INDEX ON ban (ip);
 
@@ -57,6 +64,11 @@ CREATE TABLE board (
 	maxreplies smallint NOT NULL
 	-- v3: oekaki smallint NOT NULL DEFAULT 0
 	-- v4: rules text NOT NULL DEFAULT ''
+	-- v6: flags smallint NOT NULL DEFAULT 0
+	-- v9: postsperpage smallint NOT NULL DEFAULT 0
+	-- v16: captcha smallint NOT NULL DEFAULT 0
+	-- v16: captchaprovider varchar(64) NOT NULL DEFAULT 'image'
+	-- v25: reportthreshold smallint NOT NULL DEFAULT 0
 );
 CREATE UNIQUE INDEX ON board (dir);
 
@@ -90,6 +102,11 @@ CREATE TABLE keyword (
 	id smallserial PRIMARY KEY,
 	text varchar(255) NOT NULL,
 	action varchar(255) NOT NULL
+	-- v15: fields varchar(255) NOT NULL DEFAULT 'name,email,subject,message'
+	-- v15: replace text NOT NULL DEFAULT ''
+	-- v15: banexpire bigint NOT NULL DEFAULT 0
+	-- v15: banreason text NOT NULL DEFAULT ''
+	-- v15: hits integer NOT NULL DEFAULT 0
 );
 CREATE UNIQUE INDEX ON keyword (text);
 
@@ -106,6 +123,7 @@ CREATE TABLE log (
 	timestamp bigint NOT NULL,
 	message text NOT NULL,
 	changes text NOT NULL
+	-- v23: plugin varchar(64) NOT NULL DEFAULT ''
 );
 
 CREATE TABLE post (
@@ -135,6 +153,12 @@ CREATE TABLE post (
 	stickied smallint NOT NULL default '0',
 	locked smallint NOT NULL default '0'
 	-- v5: mime varchar(64) NOT NULL default ''
+	-- v6: country varchar(2) NOT NULL default ''
+	-- v8: cycle integer NOT NULL default '0'
+	-- v8: autosage smallint NOT NULL default '0'
+	-- v8: replylimit integer NOT NULL default '0'
+	-- v13: banmessage text NOT NULL default ''
+	-- v25: reporthidden smallint NOT NULL default '0'
 );
 CREATE INDEX ON post (board);
 CREATE INDEX ON post (parent);
@@ -149,6 +173,8 @@ CREATE TABLE report (
 	post integer NOT NULL REFERENCES post (id) ON DELETE CASCADE,
 	timestamp bigint NOT NULL,
 	ip varchar(64) NOT NULL
+	-- v24: category varchar(255) NOT NULL DEFAULT ''
+	-- v24: comment text NOT NULL DEFAULT ''
 );
 CREATE UNIQUE INDEX ON report (board, post, ip);
 
@@ -161,7 +187,50 @@ CREATE UNIQUE INDEX ON report (board, post, ip);
 -- v4: 	name varchar(255) NOT NULL,
 -- v4: 	subject varchar(255) NOT NULL,
 -- v4: 	message text NOT NULL
--- v4: );`,
+-- v4: );
+
+-- v7: CREATE TABLE poll (
+-- v7: 	id serial PRIMARY KEY,
+-- v7: 	post integer NOT NULL REFERENCES post (id) ON DELETE CASCADE,
+-- v7: 	multiple smallint NOT NULL,
+-- v7: 	expire bigint NOT NULL
+-- v7: );
+-- v7: CREATE UNIQUE INDEX ON poll (post);
+
+-- v7: CREATE TABLE poll_option (
+-- v7: 	id serial PRIMARY KEY,
+-- v7: 	poll integer NOT NULL REFERENCES poll (id) ON DELETE CASCADE,
+-- v7: 	text varchar(255) NOT NULL
+-- v7: );
+-- v7: CREATE INDEX ON poll_option (poll);
+
+-- v7: CREATE TABLE poll_vote (
+-- v7: 	poll integer NOT NULL REFERENCES poll (id) ON DELETE CASCADE,
+-- v7: 	choice integer NOT NULL REFERENCES poll_option (id) ON DELETE CASCADE,
+-- v7: 	ip varchar(64) NOT NULL,
+-- v7: 	timestamp bigint NOT NULL,
+-- v7: 	PRIMARY KEY	(poll, choice, ip)
+-- v7: );
+-- v7: CREATE INDEX ON poll_vote (choice);
+
+-- v11: CREATE TABLE ban_board (
+-- v11: 	ban integer NOT NULL REFERENCES ban (id) ON DELETE CASCADE,
+-- v11: 	board smallint NOT NULL REFERENCES board (id) ON DELETE CASCADE,
+-- v11: 	PRIMARY KEY	(ban, board)
+-- v11: );
+
+-- v12: CREATE TABLE appeal (
+-- v12: 	id serial PRIMARY KEY,
+-- v12: 	ban integer NOT NULL REFERENCES ban (id) ON DELETE CASCADE,
+-- v12: 	ip varchar(64) NOT NULL,
+-- v12: 	timestamp bigint NOT NULL,
+-- v12: 	message text NOT NULL,
+-- v12: 	status smallint NOT NULL DEFAULT 0,
+-- v12: 	response text NOT NULL DEFAULT ''
+-- v12: );
+-- v12: CREATE UNIQUE INDEX ON appeal (ban);
+-- v12: CREATE INDEX ON appeal (ip);
+-- v12: CREATE INDEX ON appeal (status);`,
 	// Version 2.
 	`ALTER TABLE account ADD COLUMN style varchar(64) NOT NULL DEFAULT '';
 	UPDATE config SET value = '2' WHERE name = 'version';`,
@@ -184,4 +253,155 @@ CREATE UNIQUE INDEX ON report (board, post, ip);
 	// Version 5.
 	`ALTER TABLE post ADD COLUMN filemime varchar(64) NOT NULL default '';
 	UPDATE config SET value = '5' WHERE name = 'version';`,
+	// Version 6.
+	`ALTER TABLE board ADD COLUMN flags smallint NOT NULL DEFAULT 0;
+	ALTER TABLE post ADD COLUMN country varchar(2) NOT NULL default '';
+	UPDATE config SET value = '6' WHERE name = 'version';`,
+	// Version 7.
+	`CREATE TABLE poll (
+		id serial PRIMARY KEY,
+		post integer NOT NULL REFERENCES post (id) ON DELETE CASCADE,
+		multiple smallint NOT NULL,
+		expire bigint NOT NULL
+	);
+	CREATE UNIQUE INDEX ON poll (post);
+	CREATE TABLE poll_option (
+		id serial PRIMARY KEY,
+		poll integer NOT NULL REFERENCES poll (id) ON DELETE CASCADE,
+		text varchar(255) NOT NULL
+	);
+	CREATE INDEX ON poll_option (poll);
+	CREATE TABLE poll_vote (
+		poll integer NOT NULL REFERENCES poll (id) ON DELETE CASCADE,
+		choice integer NOT NULL REFERENCES poll_option (id) ON DELETE CASCADE,
+		ip varchar(64) NOT NULL,
+		timestamp bigint NOT NULL,
+		PRIMARY KEY	(poll, choice, ip)
+	);
+	CREATE INDEX ON poll_vote (choice);
+	UPDATE config SET value = '7' WHERE name = 'version';`,
+	// Version 8.
+	`ALTER TABLE post ADD COLUMN cycle integer NOT NULL default '0';
+	ALTER TABLE post ADD COLUMN autosage smallint NOT NULL default '0';
+	ALTER TABLE post ADD COLUMN replylimit integer NOT NULL default '0';
+	UPDATE config SET value = '8' WHERE name = 'version';`,
+	// Version 9.
+	`ALTER TABLE board ADD COLUMN postsperpage smallint NOT NULL DEFAULT 0;
+	UPDATE config SET value = '9' WHERE name = 'version';`,
+	// Version 10.
+	`ALTER TABLE ban ADD COLUMN iprange cidr NULL;
+	UPDATE config SET value = '10' WHERE name = 'version';`,
+	// Version 11.
+	`ALTER TABLE ban ADD COLUMN type smallint NOT NULL DEFAULT 0;
+	CREATE TABLE ban_board (
+		ban integer NOT NULL REFERENCES ban (id) ON DELETE CASCADE,
+		board smallint NOT NULL REFERENCES board (id) ON DELETE CASCADE,
+		PRIMARY KEY	(ban, board)
+	);
+	UPDATE config SET value = '11' WHERE name = 'version';`,
+	// Version 12.
+	`CREATE TABLE appeal (
+		id serial PRIMARY KEY,
+		ban integer NOT NULL REFERENCES ban (id) ON DELETE CASCADE,
+		ip varchar(64) NOT NULL,
+		timestamp bigint NOT NULL,
+		message text NOT NULL,
+		status smallint NOT NULL DEFAULT 0,
+		response text NOT NULL DEFAULT ''
+	);
+	CREATE UNIQUE INDEX ON appeal (ban);
+	CREATE INDEX ON appeal (ip);
+	CREATE INDEX ON appeal (status);
+	UPDATE config SET value = '12' WHERE name = 'version';`,
+	// Version 13.
+	`ALTER TABLE post ADD COLUMN banmessage text NOT NULL default '';
+	UPDATE config SET value = '13' WHERE name = 'version';`,
+	// Version 14.
+	`ALTER TABLE ban ALTER COLUMN ip TYPE text;
+	UPDATE config SET value = '14' WHERE name = 'version';`,
+	// Version 15.
+	`ALTER TABLE keyword ADD COLUMN fields varchar(255) NOT NULL DEFAULT 'name,email,subject,message';
+	ALTER TABLE keyword ADD COLUMN replace text NOT NULL DEFAULT '';
+	ALTER TABLE keyword ADD COLUMN banexpire bigint NOT NULL DEFAULT 0;
+	ALTER TABLE keyword ADD COLUMN banreason text NOT NULL DEFAULT '';
+	ALTER TABLE keyword ADD COLUMN hits integer NOT NULL DEFAULT 0;
+	UPDATE config SET value = '15' WHERE name = 'version';`,
+	// Version 16.
+	`ALTER TABLE board ADD COLUMN captcha smallint NOT NULL DEFAULT 0;
+	ALTER TABLE board ADD COLUMN captchaprovider varchar(64) NOT NULL DEFAULT 'image';
+	UPDATE board SET captcha = 1 WHERE EXISTS (SELECT 1 FROM config WHERE name = 'captcha' AND value = '1');
+	UPDATE board SET captchaprovider = 'proofofwork' WHERE EXISTS (SELECT 1 FROM config WHERE name = 'captchatype' AND value = '1');
+	DELETE FROM config WHERE name IN ('captcha', 'captchatype');
+	UPDATE config SET value = '16' WHERE name = 'version';`,
+	// Version 17.
+	`CREATE TABLE account_board (
+		account smallint NOT NULL REFERENCES account (id) ON DELETE CASCADE,
+		board smallint NOT NULL REFERENCES board (id) ON DELETE CASCADE,
+		PRIMARY KEY	(account, board)
+	);
+	UPDATE config SET value = '17' WHERE name = 'version';`,
+	// Version 18.
+	`CREATE TABLE role (
+		id serial PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		permissions text NOT NULL
+	);
+	CREATE UNIQUE INDEX ON role (name);
+	ALTER TABLE account ADD COLUMN customrole integer NOT NULL DEFAULT 0;
+	UPDATE config SET value = '18' WHERE name = 'version';`,
+	// Version 19.
+	`ALTER TABLE account ADD COLUMN totp varchar(64) NOT NULL DEFAULT '';
+	ALTER TABLE account ADD COLUMN recovery text NOT NULL DEFAULT '';
+	ALTER TABLE account ADD COLUMN totpstep bigint NOT NULL DEFAULT 0;
+	ALTER TABLE role ADD COLUMN require2fa smallint NOT NULL DEFAULT 0;
+	UPDATE config SET value = '19' WHERE name = 'version';`,
+	// Version 20.
+	`CREATE TABLE session (
+		id serial PRIMARY KEY,
+		account smallint NOT NULL REFERENCES account (id) ON DELETE CASCADE,
+		key varchar(64) NOT NULL,
+		created bigint NOT NULL,
+		lastused bigint NOT NULL,
+		useragent text NOT NULL,
+		ip varchar(64) NOT NULL
+	);
+	CREATE UNIQUE INDEX ON session (key);
+	CREATE INDEX ON session (account);
+	ALTER TABLE account DROP COLUMN session;
+	UPDATE config SET value = '20' WHERE name = 'version';`,
+	// Version 21.
+	`CREATE TABLE login_failure (
+		key varchar(255) PRIMARY KEY,
+		failures integer NOT NULL,
+		lastfailure bigint NOT NULL
+	);
+	CREATE INDEX ON login_failure (lastfailure);
+	ALTER TABLE account ADD COLUMN mustchange smallint NOT NULL DEFAULT 0;
+	UPDATE config SET value = '21' WHERE name = 'version';`,
+	// Version 22.
+	`CREATE TABLE api_token (
+		id serial PRIMARY KEY,
+		account smallint NOT NULL REFERENCES account (id) ON DELETE CASCADE,
+		name varchar(255) NOT NULL,
+		hash varchar(64) NOT NULL,
+		permissions text NOT NULL,
+		created bigint NOT NULL,
+		expires bigint NOT NULL,
+		lastused bigint NOT NULL
+	);
+	CREATE UNIQUE INDEX ON api_token (hash);
+	CREATE INDEX ON api_token (account);
+	UPDATE config SET value = '22' WHERE name = 'version';`,
+	// Version 23.
+	`ALTER TABLE log ADD COLUMN plugin varchar(64) NOT NULL DEFAULT '';
+	CREATE INDEX ON log (plugin);
+	UPDATE config SET value = '23' WHERE name = 'version';`,
+	// Version 24.
+	`ALTER TABLE report ADD COLUMN category varchar(255) NOT NULL DEFAULT '';
+	ALTER TABLE report ADD COLUMN comment text NOT NULL DEFAULT '';
+	UPDATE config SET value = '24' WHERE name = 'version';`,
+	// Version 25.
+	`ALTER TABLE board ADD COLUMN reportthreshold smallint NOT NULL DEFAULT 0;
+	ALTER TABLE post ADD COLUMN reporthidden smallint NOT NULL DEFAULT 0;
+	UPDATE config SET value = '25' WHERE name = 'version';`,
 }
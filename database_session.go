@@ -0,0 +1,118 @@
+package sriracha
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// addSession starts a new session and returns its key.
+func (db *Database) addSession(accountID int, userAgent string, ip string) string {
+	const maxUserAgent = 512
+	userAgent = strings.ToValidUTF8(userAgent, "\uFFFD")
+	if utf8.RuneCountInString(userAgent) > maxUserAgent {
+		userAgent = string([]rune(userAgent)[:maxUserAgent])
+	}
+	key := db.newSessionKey()
+	now := time.Now().Unix()
+	_, err := db.conn.Exec(context.Background(), "INSERT INTO session VALUES (DEFAULT, $1, $2, $3, $4, $5, $6)",
+		accountID,
+		key,
+		now,
+		now,
+		userAgent,
+		ip,
+	)
+	if err != nil {
+		log.Fatalf("failed to insert session: %s", err)
+	}
+	return key
+}
+
+func (db *Database) sessionByKey(key string) *Session {
+	s := &Session{}
+	err := scanSession(s, db.conn.QueryRow(context.Background(), "SELECT * FROM session WHERE key = $1", key))
+	if err == pgx.ErrNoRows {
+		return nil
+	} else if err != nil {
+		log.Fatalf("failed to select session: %s", err)
+	}
+	return s
+}
+
+// accountSessions returns the unexpired sessions of an account.
+func (db *Database) accountSessions(accountID int) []*Session {
+	rows, err := db.conn.Query(context.Background(), "SELECT * FROM session WHERE account = $1 ORDER BY lastused DESC", accountID)
+	if err != nil {
+		log.Fatalf("failed to select sessions: %s", err)
+	}
+	var sessions []*Session
+	for rows.Next() {
+		s := &Session{}
+		err = scanSession(s, rows)
+		if err != nil {
+			log.Fatalf("failed to select sessions: %s", err)
+		}
+		sessions = append(sessions, s)
+	}
+	now := time.Now().Unix()
+	var active []*Session
+	for _, s := range sessions {
+		if s.expired(&srirachaServer.opt, now) {
+			db.deleteSession(s.ID)
+			continue
+		}
+		active = append(active, s)
+	}
+	return active
+}
+
+func (db *Database) updateSessionLastUsed(id int) {
+	if id <= 0 {
+		log.Fatalf("invalid session ID %d", id)
+	}
+	_, err := db.conn.Exec(context.Background(), "UPDATE session SET lastused = $1 WHERE id = $2", time.Now().Unix(), id)
+	if err != nil {
+		log.Fatalf("failed to update session: %s", err)
+	}
+}
+
+func (db *Database) deleteSession(id int) {
+	if id <= 0 {
+		log.Fatalf("invalid session ID %d", id)
+	}
+	_, err := db.conn.Exec(context.Background(), "DELETE FROM session WHERE id = $1", id)
+	if err != nil {
+		log.Fatalf("failed to delete session: %s", err)
+	}
+}
+
+// deleteAccountSessions ends all sessions of an account, except the session
+// with the specified key.
+func (db *Database) deleteAccountSessions(accountID int, exceptKey string) int {
+	if accountID <= 0 {
+		log.Fatalf("invalid account ID %d", accountID)
+	}
+	var deleted int
+	err := db.conn.QueryRow(context.Background(), "WITH deleted AS (DELETE FROM session WHERE account = $1 AND key != $2 RETURNING *) SELECT COUNT(*) FROM deleted", accountID, exceptKey).Scan(&deleted)
+	if err != nil {
+		log.Fatalf("failed to delete sessions: %s", err)
+	}
+	return deleted
+}
+
+func scanSession(s *Session, row pgx.Row) error {
+	return row.Scan(
+		&s.ID,
+		&s.AccountID,
+		&s.Key,
+		&s.Created,
+		&s.LastUsed,
+		&s.UserAgent,
+		&s.IP,
+	)
+}
@@ -0,0 +1,136 @@
+package sriracha
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// addAPIToken adds a token and returns it. The token is not stored and may not
+// be retrieved again.
+func (db *Database) addAPIToken(t *APIToken) string {
+	buf := make([]byte, 48)
+	_, err := rand.Read(buf)
+	if err != nil {
+		panic(err)
+	}
+	token := base64.URLEncoding.EncodeToString(buf)
+	t.Hash = hashData("api " + token)
+	t.Created = time.Now().Unix()
+	err = db.conn.QueryRow(context.Background(), "INSERT INTO api_token VALUES (DEFAULT, $1, $2, $3, $4, $5, $6, 0) RETURNING id",
+		t.AccountID,
+		t.Name,
+		t.Hash,
+		joinPermissions(t.Permissions),
+		t.Created,
+		t.Expires,
+	).Scan(&t.ID)
+	if err != nil || t.ID == 0 {
+		log.Fatalf("failed to insert API token: %s", err)
+	}
+	return token
+}
+
+// accountByAPIToken returns the account of an unexpired token. The permissions
+// of the account are limited to those granted to the token.
+func (db *Database) accountByAPIToken(token string) *Account {
+	if strings.TrimSpace(token) == "" {
+		return nil
+	}
+
+	t := &APIToken{}
+	err := scanAPIToken(t, db.conn.QueryRow(context.Background(), "SELECT * FROM api_token WHERE hash = $1", hashData("api "+token)))
+	if err == pgx.ErrNoRows {
+		return nil
+	} else if err != nil {
+		log.Fatalf("failed to select API token: %s", err)
+	}
+	now := time.Now().Unix()
+	if t.expired(now) {
+		db.deleteAPIToken(t.ID)
+		return nil
+	}
+
+	a := &Account{}
+	err = scanAccount(a, db.conn.QueryRow(context.Background(), "SELECT * FROM account WHERE id = $1 AND role != $2", t.AccountID, RoleDisabled))
+	if err == pgx.ErrNoRows {
+		return nil
+	} else if err != nil {
+		log.Fatalf("failed to select account: %s", err)
+	}
+	_, err = db.conn.Exec(context.Background(), "UPDATE api_token SET lastused = $1 WHERE id = $2", now, t.ID)
+	if err != nil {
+		log.Fatalf("failed to update API token: %s", err)
+	}
+	t.LastUsed = now
+	db.fetchAccountBoards(a)
+	db.fetchAccountRole(a)
+	t.scope(a)
+	return a
+}
+
+// accountAPITokens returns the unexpired tokens of an account.
+func (db *Database) accountAPITokens(accountID int) []*APIToken {
+	rows, err := db.conn.Query(context.Background(), "SELECT * FROM api_token WHERE account = $1 ORDER BY created DESC", accountID)
+	if err != nil {
+		log.Fatalf("failed to select API tokens: %s", err)
+	}
+	var tokens []*APIToken
+	for rows.Next() {
+		t := &APIToken{}
+		err = scanAPIToken(t, rows)
+		if err != nil {
+			log.Fatalf("failed to select API tokens: %s", err)
+		}
+		tokens = append(tokens, t)
+	}
+	now := time.Now().Unix()
+	var active []*APIToken
+	for _, t := range tokens {
+		if t.expired(now) {
+			db.deleteAPIToken(t.ID)
+			continue
+		}
+		active = append(active, t)
+	}
+	return active
+}
+
+func (db *Database) deleteAPIToken(id int) {
+	if id <= 0 {
+		log.Fatalf("invalid API token ID %d", id)
+	}
+	_, err := db.conn.Exec(context.Background(), "DELETE FROM api_token WHERE id = $1", id)
+	if err != nil {
+		log.Fatalf("failed to delete API token: %s", err)
+	}
+}
+
+func scanAPIToken(t *APIToken, row pgx.Row) error {
+	var permissions string
+	err := row.Scan(
+		&t.ID,
+		&t.AccountID,
+		&t.Name,
+		&t.Hash,
+		&permissions,
+		&t.Created,
+		&t.Expires,
+		&t.LastUsed,
+	)
+	if err != nil {
+		return err
+	}
+	t.Permissions = nil
+	if permissions != "" {
+		for _, p := range strings.Split(permissions, ",") {
+			t.Permissions = append(t.Permissions, Permission(p))
+		}
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ require (
 	github.com/jackc/pgx/v5 v5.7.5
 	github.com/leonelquinteros/gotext v1.7.2
 	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
+	github.com/oschwald/maxminddb-golang v1.13.1
 	github.com/r3labs/diff/v3 v3.0.1
 	github.com/steambap/captcha v1.4.1
 	golang.org/x/exp v0.0.0-20250606033433-dcc06ee1d476
@@ -3,6 +3,7 @@ package sriracha
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -14,6 +15,7 @@ const (
 	RoleSuperAdmin AccountRole = 1
 	RoleAdmin      AccountRole = 2
 	RoleMod        AccountRole = 3
+	RoleCustom     AccountRole = 4
 	RoleDisabled   AccountRole = 99
 )
 
@@ -25,6 +27,8 @@ func formatRole(role AccountRole) string {
 		return "Administrator"
 	case RoleMod:
 		return "Moderator"
+	case RoleCustom:
+		return "Custom"
 	case RoleDisabled:
 		return "Disabled"
 	default:
@@ -38,13 +42,42 @@ type Account struct {
 	Password   string
 	Role       AccountRole
 	LastActive int64
-	Session    string
 	Style      string
+	CustomRole int
+	TOTP       string `diff:"-"`
+	Recovery   string `diff:"-"`
+	TOTPStep   int64  `diff:"-"`
+	MustChange bool   `diff:"-"`
+
+	// Calculated fields.
+	Boards      []*Board     `diff:"-"`
+	Permissions []Permission `diff:"-"`
+	RoleName    string       `diff:"-"`
+	Require2FA  bool         `diff:"-"`
+	Session     string       `diff:"-"`
+	APIToken    *APIToken    `diff:"-"`
 }
 
-func (a *Account) loadForm(r *http.Request) {
+func (a *Account) loadForm(db *Database, r *http.Request) {
 	a.Username = formString(r, "username")
 	a.Role = formRange(r, "role", RoleSuperAdmin, RoleDisabled)
+	a.CustomRole = 0
+	if a.Role == RoleCustom {
+		a.CustomRole = formInt(r, "customrole")
+	}
+	a.Boards = nil
+	boards := r.Form["boards"]
+	for _, board := range boards {
+		boardID, err := strconv.Atoi(board)
+		if err != nil || boardID <= 0 {
+			continue
+		}
+		b := db.BoardByID(boardID)
+		if b == nil {
+			continue
+		}
+		a.Boards = append(a.Boards, b)
+	}
 }
 
 func (a *Account) validate() error {
@@ -53,10 +86,152 @@ func (a *Account) validate() error {
 		return fmt.Errorf("username must be set")
 	case !alphaNumericAndSymbols.MatchString(a.Username):
 		return fmt.Errorf("username must only consist of letters, numbers, hyphens and underscores")
+	case a.Role == RoleCustom && a.CustomRole <= 0:
+		return fmt.Errorf("custom role must be set")
 	}
 	return nil
 }
 
+// Can returns whether the account has the specified permission.
+func (a *Account) Can(permission Permission) bool {
+	for _, p := range a.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAll returns whether the account has all of the specified permissions.
+func (a *Account) CanAll(permissions []Permission) bool {
+	for _, p := range permissions {
+		if !a.Can(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *Account) RoleLabel() string {
+	if a.Role == RoleCustom {
+		return a.RoleName
+	}
+	return formatRole(a.Role)
+}
+
+// TwoFactor returns whether the account has enabled two-factor authentication.
+func (a *Account) TwoFactor() bool {
+	return a.TOTP != ""
+}
+
+// Pending2FA returns whether the account must enable two-factor authentication
+// before performing any staff actions.
+func (a *Account) Pending2FA() bool {
+	return a.Require2FA && !a.TwoFactor()
+}
+
+// Restricted returns whether the account must update its preferences before
+// performing any staff actions.
+func (a *Account) Restricted() bool {
+	return a.MustChange || a.Pending2FA()
+}
+
+func (a *Account) TwoFactorLabel() string {
+	switch {
+	case a.TwoFactor():
+		return "Enabled"
+	case a.Require2FA:
+		return "Required"
+	default:
+		return "Disabled"
+	}
+}
+
+// RecoveryCodes returns the number of unused recovery codes.
+func (a *Account) RecoveryCodes() int {
+	if a.Recovery == "" {
+		return 0
+	}
+	return strings.Count(a.Recovery, ",") + 1
+}
+
+// CSRF returns the token which must be submitted with each management form as
+// the form value csrf. Tokens are derived from the session key, so each session
+// has its own token.
+func (a *Account) CSRF() string {
+	if a.Session == "" {
+		return ""
+	}
+	return hashData("csrf " + a.Session)
+}
+
+func (a *Account) HasBoard(id int) bool {
+	for _, board := range a.Boards {
+		if board.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Moderates returns whether the account may moderate the specified board.
+// Moderators assigned to specific boards may only moderate those boards.
+func (a *Account) Moderates(board *Board) bool {
+	return a.Role <= RoleAdmin || len(a.Boards) == 0 || (board != nil && a.HasBoard(board.ID))
+}
+
+// BoardScoped returns whether the account may only moderate specific boards.
+func (a *Account) BoardScoped() bool {
+	return a.Role > RoleAdmin && len(a.Boards) != 0
+}
+
+// ModeratesBan returns whether the account may manage the specified ban.
+// Moderators assigned to specific boards may only manage bans which apply
+// solely to those boards.
+func (a *Account) ModeratesBan(b *Ban) bool {
+	if !a.BoardScoped() {
+		return true
+	} else if len(b.Boards) == 0 {
+		return false
+	}
+	for _, board := range b.Boards {
+		if !a.HasBoard(board.ID) {
+			return false
+		}
+	}
+	return true
+}
+
+// scopeBoards limits the boards of a ban to the boards which the account may
+// moderate. Bans issued by moderators assigned to specific boards apply to all
+// of their boards when no other boards remain.
+func (a *Account) scopeBoards(boards []*Board) []*Board {
+	if !a.BoardScoped() {
+		return boards
+	}
+	var scoped []*Board
+	for _, board := range boards {
+		if a.HasBoard(board.ID) {
+			scoped = append(scoped, board)
+		}
+	}
+	if len(scoped) == 0 {
+		return a.Boards
+	}
+	return scoped
+}
+
+func (a *Account) BoardsLabel() string {
+	if a.Role <= RoleAdmin || len(a.Boards) == 0 {
+		return "All"
+	}
+	var paths []string
+	for _, board := range a.Boards {
+		paths = append(paths, board.Path())
+	}
+	return strings.Join(paths, " ")
+}
+
 func (a *Account) LastActiveDate() string {
 	if a.LastActive == 0 {
 		return "Never"
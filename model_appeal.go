@@ -0,0 +1,45 @@
+package sriracha
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type AppealStatus int
+
+// Appeal statuses.
+const (
+	AppealPending  AppealStatus = 0
+	AppealAccepted AppealStatus = 1
+	AppealDenied   AppealStatus = 2
+)
+
+const (
+	maxAppealLength = 2000
+	appealDelay     = time.Hour
+)
+
+type Appeal struct {
+	ID        int
+	Ban       *Ban
+	IP        string
+	Timestamp int64
+	Message   string
+	Status    AppealStatus
+	Response  string
+}
+
+func (a *Appeal) validate() error {
+	switch {
+	case strings.TrimSpace(a.Message) == "":
+		return fmt.Errorf("please enter a message")
+	case len(a.Message) > maxAppealLength:
+		return fmt.Errorf("message too long: must be at most %d characters in length", maxAppealLength)
+	}
+	return nil
+}
+
+func (a *Appeal) TimestampDate() string {
+	return time.Unix(a.Timestamp, 0).Format("2006-01-02 15:04:05 MST")
+}
@@ -3,40 +3,172 @@ package sriracha
 import (
 	"fmt"
 	"net/http"
+	"net/netip"
+	"strconv"
 	"strings"
 	"time"
 )
 
+type BanType int
+
+// Ban types.
+const (
+	BanAll     BanType = 0
+	BanPost    BanType = 1
+	BanWarning BanType = 2
+	BanReport  BanType = 3
+)
+
+func formatBanType(t BanType) string {
+	switch t {
+	case BanAll:
+		return "All access"
+	case BanPost:
+		return "Posting and reporting"
+	case BanWarning:
+		return "Warning"
+	case BanReport:
+		return "Reporting"
+	default:
+		return "Unknown"
+	}
+}
+
 type Ban struct {
 	ID        int
 	IP        string
 	Timestamp int64
 	Expire    int64
 	Reason    string
+	Range     string
+	Type      BanType
+	Boards    []*Board `diff:"-"`
 }
 
-func (b *Ban) loadForm(r *http.Request) {
+func (b *Ban) loadForm(db *Database, r *http.Request) {
 	b.Expire = formInt64(r, "expire")
 	b.Reason = formString(r, "reason")
+	b.Type = formRange(r, "type", BanAll, BanReport)
+	b.Boards = nil
+	boards := r.Form["boards"]
+	for _, board := range boards {
+		boardID, err := strconv.Atoi(board)
+		if err != nil || boardID <= 0 {
+			continue
+		}
+		bb := db.BoardByID(boardID)
+		if bb == nil {
+			continue
+		}
+		b.Boards = append(b.Boards, bb)
+	}
 }
 
 func (b *Ban) validate() error {
 	switch {
 	case strings.TrimSpace(b.IP) == "":
-		return fmt.Errorf("IP must be set")
+		return fmt.Errorf("IP, tripcode, file or name must be set")
 	case b.Expire < 0:
 		return fmt.Errorf("expiraton must be greater than or equal to zero")
+	case b.Type == BanWarning && !b.IsAddress():
+		return fmt.Errorf("warnings may only be issued to a single IP address")
 	}
 	return nil
 }
 
+func (b *Ban) HasBoard(id int) bool {
+	for _, board := range b.Boards {
+		if board.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AppliesTo returns whether the ban applies to the specified board.
+func (b *Ban) AppliesTo(board *Board) bool {
+	return len(b.Boards) == 0 || b.HasBoard(board.ID)
+}
+
+func (b *Ban) RestrictionLabel() string {
+	return formatBanType(b.Type)
+}
+
+// IsAddress returns whether the ban applies to a single IP address.
+func (b *Ban) IsAddress() bool {
+	return len(b.IP) < 2 || b.IP[1] != ' '
+}
+
+// IsRange returns whether the ban applies to a range of IP addresses.
+func (b *Ban) IsRange() bool {
+	return strings.HasPrefix(b.IP, "r ") || strings.HasPrefix(b.IP, "c ")
+}
+
+// IsTripcode returns whether the ban applies to a tripcode.
+func (b *Ban) IsTripcode() bool {
+	return strings.HasPrefix(b.IP, "t ")
+}
+
+// IsFile returns whether the ban applies to a file hash.
+func (b *Ban) IsFile() bool {
+	return strings.HasPrefix(b.IP, "f ")
+}
+
+// IsName returns whether the ban applies to names matching a regular expression.
+func (b *Ban) IsName() bool {
+	return strings.HasPrefix(b.IP, "n ")
+}
+
 func (b *Ban) TypeLabel() string {
-	if strings.HasPrefix(b.IP, "r ") {
+	if b.Range != "" {
+		return fmt.Sprintf("Range %s", b.Range)
+	} else if strings.HasPrefix(b.IP, "r ") {
 		return fmt.Sprintf("Range %s", strings.ReplaceAll(strings.ReplaceAll(b.IP[2:], `\.`, "."), ".*", "*"))
+	} else if b.IsTripcode() {
+		return fmt.Sprintf("Tripcode !%s", b.IP[2:])
+	} else if b.IsFile() {
+		return fmt.Sprintf("File %s", b.IP[2:])
+	} else if b.IsName() {
+		return fmt.Sprintf("Name /%s/", b.IP[2:])
 	}
 	return "Address"
 }
 
+// parseBanRange parses an IP address range in CIDR notation, or an IPv4
+// address range using a wildcard in place of one or more trailing octets.
+func parseBanRange(address string) (netip.Prefix, bool) {
+	address = strings.TrimSpace(address)
+	if strings.ContainsRune(address, '/') {
+		prefix, err := netip.ParsePrefix(address)
+		if err != nil {
+			return netip.Prefix{}, false
+		}
+		return prefix.Masked(), true
+	}
+
+	octets := strings.Split(address, ".")
+	if len(octets) > 4 || octets[len(octets)-1] != "*" {
+		return netip.Prefix{}, false
+	}
+	var ip [4]byte
+	var bits int
+	for i, octet := range octets {
+		if octet == "*" {
+			if i != len(octets)-1 {
+				return netip.Prefix{}, false
+			}
+			break
+		}
+		v, err := strconv.Atoi(octet)
+		if err != nil || v < 0 || v > 255 {
+			return netip.Prefix{}, false
+		}
+		ip[i] = byte(v)
+		bits += 8
+	}
+	return netip.PrefixFrom(netip.AddrFrom4(ip), bits), true
+}
+
 func (b *Ban) ExpireDate() string {
 	if b.Expire == 0 {
 		return "Never"
@@ -46,7 +178,12 @@ func (b *Ban) ExpireDate() string {
 
 func (b *Ban) Info() string {
 	var info string
-	if b.Expire == 0 {
+	if b.Type == BanWarning {
+		if b.Reason != "" {
+			info += "Reason: " + b.Reason
+		}
+		return info
+	} else if b.Expire == 0 {
 		info += "This ban is permanent."
 	} else {
 		info += fmt.Sprintf("This ban will expire at %s.", FormatTimestamp(b.Expire))
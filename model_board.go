@@ -72,39 +72,44 @@ func formatBoardApproval(a BoardApproval) string {
 }
 
 type Board struct {
-	ID            int
-	Dir           string
-	Name          string
-	Description   string
-	Type          BoardType
-	Lock          BoardLock
-	Approval      BoardApproval
-	Reports       bool
-	Style         string
-	Locale        string
-	Delay         int
-	MinName       int
-	MaxName       int
-	MinEmail      int
-	MaxEmail      int
-	MinSubject    int
-	MaxSubject    int
-	MinMessage    int
-	MaxMessage    int
-	MinSizeThread int64
-	MaxSizeThread int64
-	MinSizeReply  int64
-	MaxSizeReply  int64
-	ThumbWidth    int
-	ThumbHeight   int
-	DefaultName   string
-	WordBreak     int
-	Truncate      int
-	Threads       int
-	Replies       int
-	MaxThreads    int
-	MaxReplies    int
-	Oekaki        bool
+	ID              int
+	Dir             string
+	Name            string
+	Description     string
+	Type            BoardType
+	Lock            BoardLock
+	Approval        BoardApproval
+	Reports         bool
+	Style           string
+	Locale          string
+	Delay           int
+	MinName         int
+	MaxName         int
+	MinEmail        int
+	MaxEmail        int
+	MinSubject      int
+	MaxSubject      int
+	MinMessage      int
+	MaxMessage      int
+	MinSizeThread   int64
+	MaxSizeThread   int64
+	MinSizeReply    int64
+	MaxSizeReply    int64
+	ThumbWidth      int
+	ThumbHeight     int
+	DefaultName     string
+	WordBreak       int
+	Truncate        int
+	Threads         int
+	Replies         int
+	MaxThreads      int
+	MaxReplies      int
+	Oekaki          bool
+	Flags           bool
+	PostsPerPage    int
+	CAPTCHA         bool
+	CAPTCHAProvider string
+	ReportThreshold int
 
 	// Calculated fields.
 	Uploads []string
@@ -114,35 +119,37 @@ type Board struct {
 }
 
 const (
-	defaultBoardThreads     = 10
-	defaultBoardReplies     = 3
-	defaultBoardMaxName     = 75
-	defaultBoardMaxEmail    = 75
-	defaultBoardMaxSubject  = 75
-	defaultBoardMaxMessage  = 8000
-	defaultBoardWordBreak   = 200
-	defaultBoardDefaultName = "Anonymous"
-	defaultBoardTruncate    = 15
-	defaultBoardMaxSize     = 2097152
-	defaultBoardThumbWidth  = 250
-	defaultBoardThumbHeight = 250
+	defaultBoardThreads         = 10
+	defaultBoardReplies         = 3
+	defaultBoardMaxName         = 75
+	defaultBoardMaxEmail        = 75
+	defaultBoardMaxSubject      = 75
+	defaultBoardMaxMessage      = 8000
+	defaultBoardWordBreak       = 200
+	defaultBoardDefaultName     = "Anonymous"
+	defaultBoardTruncate        = 15
+	defaultBoardMaxSize         = 2097152
+	defaultBoardThumbWidth      = 250
+	defaultBoardThumbHeight     = 250
+	defaultBoardCAPTCHAProvider = "image"
 )
 
 func newBoard() *Board {
 	return &Board{
-		Threads:       defaultBoardThreads,
-		Replies:       defaultBoardReplies,
-		MaxName:       defaultBoardMaxName,
-		MaxEmail:      defaultBoardMaxEmail,
-		MaxSubject:    defaultBoardMaxSubject,
-		MaxMessage:    defaultBoardMaxMessage,
-		DefaultName:   defaultBoardDefaultName,
-		WordBreak:     defaultBoardWordBreak,
-		Truncate:      defaultBoardTruncate,
-		MaxSizeThread: defaultBoardMaxSize,
-		MaxSizeReply:  defaultBoardMaxSize,
-		ThumbWidth:    defaultBoardThumbWidth,
-		ThumbHeight:   defaultBoardThumbHeight,
+		Threads:         defaultBoardThreads,
+		Replies:         defaultBoardReplies,
+		MaxName:         defaultBoardMaxName,
+		MaxEmail:        defaultBoardMaxEmail,
+		MaxSubject:      defaultBoardMaxSubject,
+		MaxMessage:      defaultBoardMaxMessage,
+		DefaultName:     defaultBoardDefaultName,
+		WordBreak:       defaultBoardWordBreak,
+		Truncate:        defaultBoardTruncate,
+		MaxSizeThread:   defaultBoardMaxSize,
+		MaxSizeReply:    defaultBoardMaxSize,
+		ThumbWidth:      defaultBoardThumbWidth,
+		ThumbHeight:     defaultBoardThumbHeight,
+		CAPTCHAProvider: defaultBoardCAPTCHAProvider,
 	}
 }
 
@@ -154,6 +161,7 @@ func (b *Board) loadForm(r *http.Request, availableUploads []*uploadType, availa
 	b.Lock = formRange(r, "lock", LockNone, LockStaff)
 	b.Approval = formRange(r, "approval", ApprovalNone, ApprovalAll)
 	b.Reports = formBool(r, "reports")
+	b.ReportThreshold = formInt(r, "reportthreshold")
 	b.Style = formString(r, "style")
 	b.Locale = formString(r, "locale")
 	b.Delay = formInt(r, "delay")
@@ -180,6 +188,13 @@ func (b *Board) loadForm(r *http.Request, availableUploads []*uploadType, availa
 	b.MaxReplies = formInt(r, "maxreplies")
 	b.Oekaki = formBool(r, "oekaki")
 	b.Rules = formMultiString(r, "rules")
+	b.Flags = formBool(r, "flags")
+	b.PostsPerPage = formInt(r, "postsperpage")
+	b.CAPTCHA = formBool(r, "captcha")
+	b.CAPTCHAProvider = formString(r, "captchaprovider")
+	if captchaProviderByName(b.CAPTCHAProvider) == nil {
+		b.CAPTCHAProvider = defaultBoardCAPTCHAProvider
+	}
 
 	b.Uploads = nil
 	uploads := r.Form["uploads"]
@@ -240,6 +255,11 @@ func (b *Board) validate() error {
 	return nil
 }
 
+// paginateThreads returns whether thread pages are split into multiple pages.
+func (b *Board) paginateThreads() bool {
+	return b.Type == TypeForum && b.PostsPerPage > 0
+}
+
 func (b *Board) Path() string {
 	if b.Dir == "" {
 		return "/"
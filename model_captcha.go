@@ -1,5 +1,7 @@
 package sriracha
 
+const maxCAPTCHADifficulty = 32
+
 type CAPTCHA struct {
 	IP        string
 	Timestamp int64
@@ -2,6 +2,7 @@ package sriracha
 
 import (
 	"fmt"
+	"html"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -10,11 +11,30 @@ import (
 	"github.com/leonelquinteros/gotext"
 )
 
+// keywordFields are the post fields keywords may be matched against.
+var keywordFields = [][2]string{
+	{"name", "Name"},
+	{"email", "Email"},
+	{"subject", "Subject"},
+	{"message", "Message"},
+	{"file", "File name"},
+	{"embed", "Embed URL"},
+}
+
+// defaultKeywordFields are the post fields keywords are matched against
+// when no fields are selected.
+var defaultKeywordFields = []string{"name", "email", "subject", "message"}
+
 type Keyword struct {
-	ID     int
-	Text   string
-	Action string
-	Boards []*Board `diff:"-"`
+	ID        int
+	Text      string
+	Action    string
+	Fields    []string
+	Replace   string
+	BanExpire int64
+	BanReason string
+	Hits      int      `diff:"-"`
+	Boards    []*Board `diff:"-"`
 }
 
 func (k *Keyword) validate() error {
@@ -22,10 +42,13 @@ func (k *Keyword) validate() error {
 	case strings.TrimSpace(k.Text) == "":
 		return fmt.Errorf("text must be set")
 	case k.Action != "hide" && k.Action != "report" && k.Action != "delete" &&
+		k.Action != "captcha" && k.Action != "sage" && k.Action != "replace" &&
 		k.Action != "ban1h" && k.Action != "ban1d" && k.Action != "ban2d" &&
 		k.Action != "ban1w" && k.Action != "ban2w" && k.Action != "ban1m" &&
-		k.Action != "ban0":
+		k.Action != "ban0" && k.Action != "ban":
 		return fmt.Errorf("action must be set")
+	case k.BanExpire < 0:
+		return fmt.Errorf("ban duration must be greater than or equal to zero")
 	}
 	_, err := regexp.Compile(k.Text)
 	if err != nil {
@@ -34,6 +57,80 @@ func (k *Keyword) validate() error {
 	return nil
 }
 
+func (k *Keyword) HasField(field string) bool {
+	for _, f := range k.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// postFields returns the values of the post fields the keyword applies to.
+func (k *Keyword) postFields(p *Post) []string {
+	fields := k.Fields
+	if len(fields) == 0 {
+		fields = defaultKeywordFields
+	}
+	return keywordFieldValues(p, fields)
+}
+
+// keywordFieldValues returns the values of the specified post fields.
+func keywordFieldValues(p *Post, fields []string) []string {
+	var values []string
+	for _, field := range fields {
+		switch field {
+		case "name":
+			values = append(values, p.Name)
+		case "email":
+			values = append(values, p.Email)
+		case "subject":
+			values = append(values, p.Subject)
+		case "message":
+			values = append(values, p.Message)
+		case "file":
+			if p.File != "" && !p.IsEmbed() {
+				values = append(values, p.FileOriginal)
+			}
+		case "embed":
+			if p.IsEmbed() {
+				values = append(values, p.FileOriginal)
+			}
+		}
+	}
+	return values
+}
+
+func (k *Keyword) matchPost(rgxp *regexp.Regexp, p *Post) bool {
+	for _, value := range k.postFields(p) {
+		if rgxp.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// replacePost replaces matches in the name, email, subject and message of a
+// post. File names and embed URLs are not modified.
+func (k *Keyword) replacePost(rgxp *regexp.Regexp, p *Post) {
+	fields := k.Fields
+	if len(fields) == 0 {
+		fields = defaultKeywordFields
+	}
+	for _, field := range fields {
+		switch field {
+		case "name":
+			p.Name = rgxp.ReplaceAllString(p.Name, k.Replace)
+		case "email":
+			p.Email = rgxp.ReplaceAllString(p.Email, k.Replace)
+		case "subject":
+			p.Subject = rgxp.ReplaceAllString(p.Subject, k.Replace)
+		case "message":
+			p.Message = rgxp.ReplaceAllString(p.Message, html.EscapeString(k.Replace))
+		}
+	}
+}
+
 func (k *Keyword) HasBoard(id int) bool {
 	for _, b := range k.Boards {
 		if b.ID == id {
@@ -46,6 +143,24 @@ func (k *Keyword) HasBoard(id int) bool {
 func (k *Keyword) loadForm(db *Database, r *http.Request) {
 	k.Text = formString(r, "text")
 	k.Action = formString(r, "action")
+	k.Replace = formString(r, "replace")
+	k.BanExpire = formInt64(r, "banexpire")
+	k.BanReason = formString(r, "banreason")
+
+	k.Fields = nil
+	fields := r.Form["fields"]
+	for _, field := range fields {
+		for _, info := range keywordFields {
+			if info[0] == field {
+				k.Fields = append(k.Fields, field)
+				break
+			}
+		}
+	}
+	if len(k.Fields) == 0 {
+		k.Fields = append(k.Fields, defaultKeywordFields...)
+	}
+
 	k.Boards = nil
 	boards := r.Form["boards"]
 	for _, board := range boards {
@@ -70,6 +185,12 @@ func (k *Keyword) ActionLabel() string {
 		label = "Report"
 	case "delete":
 		label = "Delete"
+	case "captcha":
+		label = "Require CAPTCHA"
+	case "sage":
+		label = "Force sage"
+	case "replace":
+		label = "Replace"
 	case "ban1h":
 		label = "Delete & ban for 1 hour"
 	case "ban1d":
@@ -84,8 +205,24 @@ func (k *Keyword) ActionLabel() string {
 		label = "Delete & ban for 1 month"
 	case "ban0":
 		label = "Delete & ban permanently"
+	case "ban":
+		if k.BanExpire == 0 {
+			label = "Delete & ban permanently"
+		} else {
+			return gotext.Get("Delete & ban for %d seconds", k.BanExpire)
+		}
 	default:
 		label = "Unknown"
 	}
 	return gotext.Get(label)
 }
+
+func (k *Keyword) FieldsLabel() string {
+	var labels []string
+	for _, info := range keywordFields {
+		if k.HasField(info[0]) {
+			labels = append(labels, info[1])
+		}
+	}
+	return strings.Join(labels, ", ")
+}
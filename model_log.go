@@ -1,10 +1,15 @@
 package sriracha
 
 import (
+	"fmt"
 	"html"
 	"html/template"
 	"log"
+	"net/http"
+	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,12 +20,26 @@ type Log struct {
 	Timestamp int64
 	Message   string
 	Changes   string
+	Plugin    string
 }
 
 func (l *Log) TimestampDate() string {
 	return time.Unix(l.Timestamp, 0).Format("2006-01-02 15:04:05 MST")
 }
 
+// UserLabel returns the username of the account which performed the action,
+// followed by the name of the plugin which performed it, if any.
+func (l *Log) UserLabel() string {
+	label := "System"
+	if l.Account != nil {
+		label = l.Account.Username
+	}
+	if l.Plugin != "" {
+		label += " (" + l.Plugin + ")"
+	}
+	return label
+}
+
 func (l *Log) formatLabel(message string) template.HTML {
 	if len(message) == 0 {
 		return ""
@@ -40,3 +59,157 @@ func (l *Log) MessageLabel() template.HTML {
 func (l *Log) InfoLabel() template.HTML {
 	return l.formatLabel(l.Changes)
 }
+
+// logAction is a type of logged action. Logs are matched by message.
+type logAction struct {
+	Name     string
+	Label    string
+	patterns []string
+}
+
+var logActions = []*logAction{
+	{"ban", "Bans and appeals", []string{"%>>/ban/%"}},
+	{"delete", "Post deletions", []string{"Deleted No.%"}},
+	{"post", "Post moderation", []string{"%>>/post/%", "Detected flood%"}},
+	{"board", "Boards", []string{"%>>/board/%"}},
+	{"account", "Accounts and roles", []string{"%>>/account/%", "Added role %", "Updated role %", "Deleted role %"}},
+	{"keyword", "Keywords", []string{"%>>/keyword/%"}},
+	{"news", "News", []string{"%>>/news/%"}},
+	{"setting", "Settings", []string{"Updated settings%", "Reset settings%"}},
+	{"plugin", "Plugins", []string{"Updated plugin %", "Reset plugin %"}},
+	{"security", "Logins and security", []string{"% login as %", "%two-factor%", "Added API token %", "Revoked API token %"}},
+}
+
+func logActionByName(name string) *logAction {
+	for _, action := range logActions {
+		if action.Name == name {
+			return action
+		}
+	}
+	return nil
+}
+
+// logQuery limits the logs which are listed and exported.
+type logQuery struct {
+	Account int
+	Board   int
+	Action  string
+	Plugin  string
+	Start   string
+	End     string
+	Post    int
+	Ban     int
+}
+
+func (q *logQuery) loadForm(r *http.Request) {
+	q.Account = formInt(r, "account")
+	q.Board = formInt(r, "board")
+	q.Action = formString(r, "type")
+	if logActionByName(q.Action) == nil {
+		q.Action = ""
+	}
+	q.Plugin = strings.ToLower(formString(r, "plugin"))
+	q.Start = formString(r, "start")
+	if _, ok := parseLogDate(q.Start); !ok {
+		q.Start = ""
+	}
+	q.End = formString(r, "end")
+	if _, ok := parseLogDate(q.End); !ok {
+		q.End = ""
+	}
+	q.Post = max(formInt(r, "post"), 0)
+	q.Ban = max(formInt(r, "ban"), 0)
+}
+
+// Encode returns the query as URL query values.
+func (q *logQuery) Encode() string {
+	values := url.Values{}
+	setInt := func(key string, value int) {
+		if value > 0 {
+			values.Set(key, strconv.Itoa(value))
+		}
+	}
+	setString := func(key string, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	setInt("account", q.Account)
+	setInt("board", q.Board)
+	setString("type", q.Action)
+	setString("plugin", q.Plugin)
+	setString("start", q.Start)
+	setString("end", q.End)
+	setInt("post", q.Post)
+	setInt("ban", q.Ban)
+	return values.Encode()
+}
+
+// URLSuffix returns the query as a URL suffix, including the leading question
+// mark, or a blank string when no filters are set.
+func (q *logQuery) URLSuffix() string {
+	encoded := q.Encode()
+	if encoded == "" {
+		return ""
+	}
+	return "?" + encoded
+}
+
+// where returns a condition which limits logs to those matching the query and
+// visible to the specified account. Moderators assigned to specific boards may
+// only view logs of those boards and of their own actions.
+func (q *logQuery) where(a *Account) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, values ...interface{}) {
+		for _, v := range values {
+			args = append(args, v)
+			condition = strings.Replace(condition, "?", fmt.Sprintf("$%d", len(args)), 1)
+		}
+		conditions = append(conditions, condition)
+	}
+	if a != nil && a.BoardScoped() {
+		boardIDs := make([]int, len(a.Boards))
+		for i, b := range a.Boards {
+			boardIDs[i] = b.ID
+		}
+		add("(board = ANY(?) OR account = ?)", boardIDs, a.ID)
+	}
+	if q.Account > 0 {
+		add("account = ?", q.Account)
+	}
+	if q.Board > 0 {
+		add("board = ?", q.Board)
+	}
+	if action := logActionByName(q.Action); action != nil {
+		add("message LIKE ANY(?)", action.patterns)
+	}
+	if q.Plugin != "" {
+		add("plugin = ?", q.Plugin)
+	}
+	if start, ok := parseLogDate(q.Start); ok {
+		add("timestamp >= ?", start.Unix())
+	}
+	if end, ok := parseLogDate(q.End); ok {
+		add("timestamp < ?", end.AddDate(0, 0, 1).Unix())
+	}
+	if q.Post > 0 {
+		add("(message ~ ? OR message ~ ?)", fmt.Sprintf(">>/post/%d([^0-9]|$)", q.Post), fmt.Sprintf("^Deleted No\\.%d([^0-9]|$)", q.Post))
+	}
+	if q.Ban > 0 {
+		add("message ~ ?", fmt.Sprintf(">>/ban/%d([^0-9]|$)", q.Ban))
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// parseLogDate parses a date in the format YYYY-MM-DD.
+func parseLogDate(date string) (time.Time, bool) {
+	if date == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	return t, err == nil
+}
@@ -43,14 +43,14 @@ func (n *News) MayUpdate(a *Account) bool {
 	if a == nil {
 		return false
 	}
-	return n.Share || (n.Account != nil && n.Account.ID == a.ID) || (n.Account == nil && (a.Role == RoleSuperAdmin || a.Role == RoleAdmin))
+	return n.Share || (n.Account != nil && n.Account.ID == a.ID) || (n.Account == nil && a.Can(PermissionAllNews))
 }
 
 func (n *News) MayDelete(a *Account) bool {
 	if a == nil {
 		return false
 	}
-	return a.Role == RoleSuperAdmin || a.Role == RoleAdmin || n.MayUpdate(a)
+	return a.Can(PermissionAllNews) || n.MayUpdate(a)
 }
 
 func (n *News) DateLabel() string {
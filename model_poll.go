@@ -0,0 +1,96 @@
+package sriracha
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxPollOptions      = 10
+	maxPollOptionLength = 255
+)
+
+type Poll struct {
+	ID       int
+	Post     int
+	Multiple bool
+	Expire   int64
+
+	// Calculated fields.
+	Options []*PollOption
+	Voters  int
+}
+
+type PollOption struct {
+	ID   int
+	Poll int
+	Text string
+
+	// Calculated fields.
+	Votes int
+}
+
+func (p *Poll) loadForm(r *http.Request) {
+	p.Options = nil
+	for _, line := range strings.Split(r.FormValue("poll"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		p.Options = append(p.Options, &PollOption{Text: line})
+	}
+	p.Multiple = formBool(r, "pollmultiple")
+	p.Expire = 0
+	hours := formInt(r, "pollexpire")
+	if hours > 0 {
+		p.Expire = time.Now().Add(time.Duration(hours) * time.Hour).Unix()
+	}
+}
+
+func (p *Poll) validate() error {
+	switch {
+	case len(p.Options) < 2:
+		return fmt.Errorf("polls must have at least 2 options")
+	case len(p.Options) > maxPollOptions:
+		return fmt.Errorf("polls may have at most %d options", maxPollOptions)
+	}
+	for _, o := range p.Options {
+		if !utf8.ValidString(o.Text) {
+			return fmt.Errorf("poll options must be valid text")
+		} else if utf8.RuneCountInString(o.Text) > maxPollOptionLength {
+			return fmt.Errorf("poll options must be %d characters or less", maxPollOptionLength)
+		}
+	}
+	return nil
+}
+
+func (p *Poll) HasOption(id int) bool {
+	for _, o := range p.Options {
+		if o.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Poll) Closed() bool {
+	return p.Expire != 0 && p.Expire <= time.Now().Unix()
+}
+
+func (p *Poll) ExpireLabel() string {
+	return FormatTimestamp(p.Expire)
+}
+
+func (p *Poll) Percent(o *PollOption) int {
+	var total int
+	for _, option := range p.Options {
+		total += option.Votes
+	}
+	if total == 0 {
+		return 0
+	}
+	return o.Votes * 100 / total
+}
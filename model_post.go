@@ -67,9 +67,17 @@ type Post struct {
 	Moderated    PostModerated
 	Stickied     bool
 	Locked       bool
+	Country      string
+	Cycle        int
+	Autosage     bool
+	ReplyLimit   int
+	BanMessage   string
+	ReportHidden bool
 
 	// Calculated fields.
 	Replies int
+	Page    int   `diff:"-"`
+	Poll    *Poll `diff:"-"`
 }
 
 func (p *Post) Copy() *Post {
@@ -79,6 +87,12 @@ func (p *Post) Copy() *Post {
 	return pp
 }
 
+func (p *Post) loadThreadForm(r *http.Request) {
+	p.Cycle = max(formInt(r, "cycle"), 0)
+	p.Autosage = formBool(r, "autosage")
+	p.ReplyLimit = max(formInt(r, "replylimit"), 0)
+}
+
 var postUploadFileLock = &sync.Mutex{}
 
 func (p *Post) setFileAndThumb(fileExt string, thumbExt string) {
@@ -449,6 +463,10 @@ func (p *Post) setNameBlock(defaultName string, capcode string) {
 		out.WriteString(`</a>`)
 	}
 
+	if p.Country != "" {
+		out.WriteString(` <span class="posterflag" title="` + p.Country + `">` + countryFlag(p.Country) + `</span>`)
+	}
+
 	if capcode != "" {
 		spanColor := "red"
 		if capcode == "Admin" {
@@ -469,6 +487,14 @@ func (p *Post) Thread() int {
 	return p.Parent
 }
 
+// ThreadFile returns the name of the thread page which contains the post.
+func (p *Post) ThreadFile() string {
+	if p.Page == 0 {
+		return fmt.Sprintf("%d.html", p.Thread())
+	}
+	return fmt.Sprintf("%d-%d.html", p.Thread(), p.Page+1)
+}
+
 func (p *Post) FileSizeLabel() string {
 	return FormatFileSize(p.FileSize)
 }
@@ -575,7 +601,7 @@ func (p *Post) ExpandHTML() template.HTML {
 }
 
 func (p *Post) RefLink() template.HTML {
-	return template.HTML(fmt.Sprintf(`<a href="%sres/%d.html#%d">&gt;&gt;%d</a>`, p.Board.Path(), p.Thread(), p.ID, p.ID))
+	return template.HTML(fmt.Sprintf(`<a href="%sres/%s#%d">&gt;&gt;%d</a>`, p.Board.Path(), p.ThreadFile(), p.ID, p.ID))
 }
 
 func mimeToExt(mimeType string) string {
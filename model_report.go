@@ -1,15 +1,69 @@
 package sriracha
 
+import (
+	"time"
+)
+
 type Report struct {
 	ID        int
 	Board     *Board
 	Post      *Post
 	Timestamp int64
 	IP        string
+	Category  string
+	Comment   string
+
+	count   int
+	reports []*Report
+}
 
-	count int
+// reportCategoryCount is the number of reports of a post in a category.
+type reportCategoryCount struct {
+	Category string
+	Count    int
 }
 
 func (r *Report) Count() int {
 	return r.count
 }
+
+// Reports returns the individual reports of a reported post.
+func (r *Report) Reports() []*Report {
+	return r.reports
+}
+
+// CategoryCounts returns the number of reports of a reported post in each
+// category, in the order each category was first reported.
+func (r *Report) CategoryCounts() []*reportCategoryCount {
+	var counts []*reportCategoryCount
+	index := make(map[string]*reportCategoryCount)
+	for _, report := range r.reports {
+		c := index[report.CategoryLabel()]
+		if c == nil {
+			c = &reportCategoryCount{Category: report.CategoryLabel()}
+			index[c.Category] = c
+			counts = append(counts, c)
+		}
+		c.Count++
+	}
+	return counts
+}
+
+func (r *Report) CategoryLabel() string {
+	if r.Category == "" {
+		return "Uncategorized"
+	}
+	return r.Category
+}
+
+func (r *Report) TimestampDate() string {
+	return time.Unix(r.Timestamp, 0).Format("2006-01-02 15:04:05 MST")
+}
+
+// IPLabel returns a shortened IP address hash.
+func (r *Report) IPLabel() string {
+	if len(r.IP) > 12 {
+		return r.IP[:12]
+	}
+	return r.IP
+}
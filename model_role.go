@@ -0,0 +1,183 @@
+package sriracha
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type Permission string
+
+// Permissions.
+const (
+	PermissionAccount      Permission = "account"
+	PermissionBoard        Permission = "board"
+	PermissionDeleteBoard  Permission = "deleteboard"
+	PermissionKeyword      Permission = "keyword"
+	PermissionSetting      Permission = "setting"
+	PermissionPlugin       Permission = "plugin"
+	PermissionImport       Permission = "import"
+	PermissionBan          Permission = "ban"
+	PermissionLiftBan      Permission = "liftban"
+	PermissionAppeal       Permission = "appeal"
+	PermissionApprove      Permission = "approve"
+	PermissionDelete       Permission = "delete"
+	PermissionThread       Permission = "thread"
+	PermissionCapcode      Permission = "capcode"
+	PermissionAdminCapcode Permission = "admincapcode"
+	PermissionRawHTML      Permission = "rawhtml"
+	PermissionNews         Permission = "news"
+	PermissionAllNews      Permission = "allnews"
+	PermissionLog          Permission = "log"
+)
+
+var allPermissions = []Permission{
+	PermissionAccount,
+	PermissionBoard,
+	PermissionDeleteBoard,
+	PermissionKeyword,
+	PermissionSetting,
+	PermissionPlugin,
+	PermissionImport,
+	PermissionBan,
+	PermissionLiftBan,
+	PermissionAppeal,
+	PermissionApprove,
+	PermissionDelete,
+	PermissionThread,
+	PermissionCapcode,
+	PermissionAdminCapcode,
+	PermissionRawHTML,
+	PermissionNews,
+	PermissionAllNews,
+	PermissionLog,
+}
+
+func formatPermission(p Permission) string {
+	switch p {
+	case PermissionAccount:
+		return "Manage accounts"
+	case PermissionBoard:
+		return "Add and update boards"
+	case PermissionDeleteBoard:
+		return "Delete boards"
+	case PermissionKeyword:
+		return "Manage keywords"
+	case PermissionSetting:
+		return "Update settings"
+	case PermissionPlugin:
+		return "Configure plugins"
+	case PermissionImport:
+		return "Import boards"
+	case PermissionBan:
+		return "Add and extend bans"
+	case PermissionLiftBan:
+		return "Lift and shorten bans"
+	case PermissionAppeal:
+		return "Review appeals"
+	case PermissionApprove:
+		return "Review reports and approve posts"
+	case PermissionDelete:
+		return "Delete posts"
+	case PermissionThread:
+		return "Sticky, lock and set thread options"
+	case PermissionCapcode:
+		return "Post as staff with the Mod capcode"
+	case PermissionAdminCapcode:
+		return "Post as staff with the Admin capcode"
+	case PermissionRawHTML:
+		return "Post raw HTML as staff"
+	case PermissionNews:
+		return "Add and update news"
+	case PermissionAllNews:
+		return "Delete all news"
+	case PermissionLog:
+		return "View logs"
+	default:
+		return "Unknown"
+	}
+}
+
+func (p Permission) Label() string {
+	return formatPermission(p)
+}
+
+// rolePermissions returns the permissions of a built-in role.
+func rolePermissions(role AccountRole) []Permission {
+	switch role {
+	case RoleSuperAdmin:
+		return allPermissions
+	case RoleAdmin:
+		var permissions []Permission
+		for _, p := range allPermissions {
+			if p != PermissionAccount && p != PermissionDeleteBoard && p != PermissionImport {
+				permissions = append(permissions, p)
+			}
+		}
+		return permissions
+	case RoleMod:
+		return []Permission{
+			PermissionBan,
+			PermissionAppeal,
+			PermissionApprove,
+			PermissionDelete,
+			PermissionThread,
+			PermissionCapcode,
+			PermissionRawHTML,
+			PermissionNews,
+			PermissionLog,
+		}
+	default:
+		return nil
+	}
+}
+
+// Role is a custom set of permissions which may be assigned to accounts.
+type Role struct {
+	ID          int
+	Name        string
+	Permissions []Permission
+	Require2FA  bool
+}
+
+func (r *Role) loadForm(req *http.Request) {
+	r.Name = formString(req, "name")
+	r.Require2FA = formBool(req, "require2fa")
+	r.Permissions = nil
+	for _, value := range req.Form["permissions"] {
+		for _, p := range allPermissions {
+			if Permission(value) == p && !r.Has(p) {
+				r.Permissions = append(r.Permissions, p)
+				break
+			}
+		}
+	}
+}
+
+func (r *Role) validate() error {
+	switch {
+	case strings.TrimSpace(r.Name) == "":
+		return fmt.Errorf("name must be set")
+	}
+	return nil
+}
+
+func (r *Role) Has(permission Permission) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Role) PermissionsLabel() string {
+	if len(r.Permissions) == 0 {
+		return "None"
+	}
+	var labels []string
+	for _, p := range r.Permissions {
+		labels = append(labels, p.Label())
+	}
+	return strings.Join(labels, ", ")
+}
@@ -0,0 +1,41 @@
+package sriracha
+
+import (
+	"time"
+)
+
+// Session is a logged in staff session. Each account may have multiple
+// concurrent sessions.
+type Session struct {
+	ID        int
+	AccountID int
+	Key       string
+	Created   int64
+	LastUsed  int64
+	UserAgent string
+	IP        string
+}
+
+// expired returns whether the session has been idle for too long or has
+// reached its maximum age.
+func (s *Session) expired(opt *ServerOptions, now int64) bool {
+	const hour = 60 * 60
+	return (opt.SessionIdle > 0 && now-s.LastUsed >= int64(opt.SessionIdle)*hour) ||
+		(opt.SessionExpire > 0 && now-s.Created >= int64(opt.SessionExpire)*hour)
+}
+
+func (s *Session) CreatedDate() string {
+	return time.Unix(s.Created, 0).Format("2006-01-02 15:04:05 MST")
+}
+
+func (s *Session) LastUsedDate() string {
+	return time.Unix(s.LastUsed, 0).Format("2006-01-02 15:04:05 MST")
+}
+
+// IPLabel returns a shortened IP address hash.
+func (s *Session) IPLabel() string {
+	if len(s.IP) > 12 {
+		return s.IP[:12]
+	}
+	return s.IP
+}
@@ -0,0 +1,112 @@
+package sriracha
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiPermissions are the permissions which may be granted to API tokens.
+var apiPermissions = []Permission{
+	PermissionBan,
+	PermissionLiftBan,
+	PermissionApprove,
+	PermissionDelete,
+	PermissionThread,
+}
+
+// APIToken is a named token which authenticates requests to the management
+// API on behalf of an account. Only the hash of the token is stored.
+type APIToken struct {
+	ID          int
+	AccountID   int
+	Name        string
+	Hash        string
+	Permissions []Permission
+	Created     int64
+	Expires     int64
+	LastUsed    int64
+}
+
+// loadForm loads the token form. Only permissions of the specified account
+// may be granted.
+func (t *APIToken) loadForm(r *http.Request, a *Account) {
+	t.Name = strings.TrimSpace(formString(r, "name"))
+	t.Permissions = nil
+	for _, value := range r.Form["permissions"] {
+		for _, p := range apiPermissions {
+			if Permission(value) == p && a.Can(p) && !t.Has(p) {
+				t.Permissions = append(t.Permissions, p)
+				break
+			}
+		}
+	}
+	t.Expires = 0
+	if days := formInt(r, "expire"); days > 0 {
+		t.Expires = time.Now().Unix() + int64(days)*60*60*24
+	}
+}
+
+func (t *APIToken) validate() error {
+	switch {
+	case t.Name == "":
+		return fmt.Errorf("name must be set")
+	case len(t.Name) > 255:
+		return fmt.Errorf("name must be 255 characters or less")
+	case len(t.Permissions) == 0:
+		return fmt.Errorf("at least one permission must be granted")
+	}
+	return nil
+}
+
+func (t *APIToken) Has(permission Permission) bool {
+	for _, p := range t.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *APIToken) expired(now int64) bool {
+	return t.Expires != 0 && now >= t.Expires
+}
+
+// scope restricts the permissions of an account to those granted to the token.
+func (t *APIToken) scope(a *Account) {
+	var permissions []Permission
+	for _, p := range a.Permissions {
+		if t.Has(p) {
+			permissions = append(permissions, p)
+		}
+	}
+	a.Permissions = permissions
+	a.APIToken = t
+}
+
+func (t *APIToken) PermissionsLabel() string {
+	var labels []string
+	for _, p := range t.Permissions {
+		labels = append(labels, p.Label())
+	}
+	return strings.Join(labels, ", ")
+}
+
+func (t *APIToken) CreatedDate() string {
+	return time.Unix(t.Created, 0).Format("2006-01-02 15:04:05 MST")
+}
+
+func (t *APIToken) ExpiresDate() string {
+	if t.Expires == 0 {
+		return "Never"
+	}
+	return time.Unix(t.Expires, 0).Format("2006-01-02 15:04:05 MST")
+}
+
+func (t *APIToken) LastUsedDate() string {
+	if t.LastUsed == 0 {
+		return "Never"
+	}
+	return time.Unix(t.LastUsed, 0).Format("2006-01-02 15:04:05 MST")
+}
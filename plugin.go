@@ -120,17 +120,34 @@ type PluginWithInsert interface {
 type PluginWithServe interface {
 	Plugin
 
-	// Serve handles plugin web requests. Only administrators and super-administrators
-	// may access this page. When serving HTML responses, return the HTML and a
+	// Serve handles plugin web requests. Only accounts with permission to
+	// configure plugins may access this page. When serving HTML responses, return the HTML and a
 	// nil error. When serving any other content type, set the Conent-Type header,
 	// write to the http.ResponseWriter directly and return a blank string.
+	// POST requests are rejected unless they include the form value csrf,
+	// which must be set to the token returned by Account.CSRF.
 	Serve(db *Database, a *Account, w http.ResponseWriter, r *http.Request) (string, error)
 }
 
+// PluginWithCAPTCHA describes the required methods for a plugin providing a CAPTCHA.
+type PluginWithCAPTCHA interface {
+	Plugin
+
+	// CAPTCHA returns the HTML of a CAPTCHA challenge. This HTML is included in
+	// the new post form of each board which uses the plugin as its CAPTCHA
+	// provider. Board pages are static, so the same HTML is shown to every
+	// visitor. Challenges unique to each visitor must be loaded by the browser.
+	CAPTCHA(board *Board) string
+
+	// Solve returns whether the CAPTCHA challenge was solved. The request
+	// contains the submitted new post form.
+	Solve(db *Database, r *http.Request) bool
+}
+
 // RegisterPlugin registers a Sriracha plugin to receive any subscribed events.
 // Plugins must call this function in init(). See [PluginWithConfig],
-// [PluginWithUpdate], [PluginWithPost], [PluginWithInsert] and
-// [PluginWithServe].
+// [PluginWithUpdate], [PluginWithPost], [PluginWithInsert], [PluginWithServe]
+// and [PluginWithCAPTCHA].
 func RegisterPlugin(plugin any) {
 	if srirachaServer == nil {
 		panic("Sriracha server not yet started")
@@ -198,6 +215,15 @@ func RegisterPlugin(plugin any) {
 		allPluginServeHandlers = append(allPluginServeHandlers, serveHandlerInfo{strings.ToLower(name), pServe.Serve})
 	}
 
+	pCAPTCHA, ok := plugin.(PluginWithCAPTCHA)
+	if ok {
+		if captchaProviderByName(strings.ToLower(name)) != nil {
+			log.Fatalf("%s CAPTCHA provider is already registered", name)
+		}
+		events = append(events, "CAPTCHA")
+		allCAPTCHAProviders = append(allCAPTCHAProviders, &captchaProviderInfo{strings.ToLower(name), name, pCAPTCHA, true})
+	}
+
 	if len(events) == 0 {
 		events = append(events, "None")
 	}
@@ -239,6 +265,13 @@ type serveHandlerInfo struct {
 	Handler serveHandler
 }
 
+type captchaProviderInfo struct {
+	Name     string
+	Label    string
+	Provider PluginWithCAPTCHA
+	plugin   bool
+}
+
 type pluginInfo struct {
 	ID     int
 	Name   string
@@ -253,3 +286,7 @@ var allPluginInfo []*pluginInfo
 var allPluginPostHandlers []postHandlerInfo
 var allPluginInsertHandlers []insertHandlerInfo
 var allPluginServeHandlers []serveHandlerInfo
+var allCAPTCHAProviders = []*captchaProviderInfo{
+	{"image", "Image", &imageCAPTCHA{}, false},
+	{"proofofwork", "Proof of work", &proofOfWorkCAPTCHA{}, false},
+}
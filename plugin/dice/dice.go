@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"codeberg.org/tslocum/sriracha"
+)
+
+const (
+	configSyntax   = "syntax"
+	configDefault  = "default"
+	configMaxDice  = "maxdice"
+	configMaxSides = "maxsides"
+	configMaxRolls = "maxrolls"
+	configBoards   = "boards"
+
+	syntaxHash   = 0
+	syntaxBBCode = 1
+	syntaxBoth   = 2
+
+	// Limits enforced regardless of configuration.
+	limitDice     = 100
+	limitSides    = 1000000
+	limitModifier = 1000000
+)
+
+var (
+	hashPattern   = regexp.MustCompile(`(?i)##([0-9]*)d([0-9]+)([+-][0-9]+)?\b`)
+	bbcodePattern = regexp.MustCompile(`(?i)\[roll(?:\s+([0-9]*)d([0-9]+)([+-][0-9]+)?)?\]`)
+	dicePattern   = regexp.MustCompile(`(?i)^([0-9]*)d([0-9]+)([+-][0-9]+)?$`)
+)
+
+type Dice struct {
+	syntax   int
+	dice     []string
+	maxDice  int
+	maxSides int
+	maxRolls int
+	boards   []int
+}
+
+func (d *Dice) About() string {
+	return "Roll dice in post messages."
+}
+
+func (d *Dice) Config() []sriracha.PluginConfig {
+	return []sriracha.PluginConfig{
+		{
+			Type:        sriracha.TypeEnum,
+			Name:        configSyntax,
+			Default:     "##2d6|||[roll 2d6]|||Both",
+			Description: "Syntax used to roll dice. A modifier may be added to any roll, such as ##2d6+3.",
+		}, {
+			Type:        sriracha.TypeString,
+			Name:        configDefault,
+			Default:     "1d6",
+			Description: "Dice rolled when [roll] is entered without specifying any dice.",
+		}, {
+			Type:        sriracha.TypeInteger,
+			Name:        configMaxDice,
+			Default:     "10",
+			Description: fmt.Sprintf("Maximum number of dice in a single roll. At most %d dice may be rolled at once.", limitDice),
+		}, {
+			Type:        sriracha.TypeInteger,
+			Name:        configMaxSides,
+			Default:     "100",
+			Description: fmt.Sprintf("Maximum number of sides each die may have. Dice may have at most %d sides.", limitSides),
+		}, {
+			Type:        sriracha.TypeInteger,
+			Name:        configMaxRolls,
+			Default:     "5",
+			Description: "Maximum number of rolls in a single post. Additional rolls are left as they were entered.",
+		}, {
+			Type:        sriracha.TypeBoard,
+			Multiple:    true,
+			Name:        configBoards,
+			Description: "Only roll dice in the selected boards.",
+		},
+	}
+}
+
+func (d *Dice) Update(db *sriracha.Database, key string) error {
+	switch key {
+	case configSyntax:
+		d.syntax = db.GetInt(configSyntax)
+	case configDefault:
+		d.dice = dicePattern.FindStringSubmatch(strings.TrimSpace(db.GetString(configDefault)))
+	case configMaxDice:
+		d.maxDice = db.GetInt(configMaxDice)
+	case configMaxSides:
+		d.maxSides = db.GetInt(configMaxSides)
+	case configMaxRolls:
+		d.maxRolls = db.GetInt(configMaxRolls)
+	case configBoards:
+		d.boards = db.GetMultiInt(configBoards)
+	}
+	return nil
+}
+
+func (d *Dice) roll(match []string) (string, error) {
+	maxDice := limitDice
+	if d.maxDice > 0 && d.maxDice < maxDice {
+		maxDice = d.maxDice
+	}
+	maxSides := limitSides
+	if d.maxSides > 0 && d.maxSides < maxSides {
+		maxSides = d.maxSides
+	}
+
+	count := 1
+	if match[1] != "" {
+		var err error
+		count, err = strconv.Atoi(match[1])
+		if err != nil || count > maxDice {
+			return "", fmt.Errorf("you may roll at most %d dice at once", maxDice)
+		}
+	}
+	sides, err := strconv.Atoi(match[2])
+	if err != nil || sides > maxSides {
+		return "", fmt.Errorf("dice may have at most %d sides", maxSides)
+	}
+	var modifier int
+	if match[3] != "" {
+		modifier, err = strconv.Atoi(match[3])
+		if err != nil || modifier > limitModifier || modifier < -limitModifier {
+			return "", fmt.Errorf("modifier must be between %d and %d", -limitModifier, limitModifier)
+		}
+	}
+	switch {
+	case count < 1:
+		return "", fmt.Errorf("you must roll at least 1 die")
+	case sides < 2:
+		return "", fmt.Errorf("dice must have at least 2 sides")
+	}
+
+	rolls := make([]string, count)
+	total := modifier
+	for i := range rolls {
+		v := rand.Intn(sides) + 1
+		total += v
+		rolls[i] = strconv.Itoa(v)
+	}
+
+	label := fmt.Sprintf("%dd%d", count, sides)
+	result := strings.Join(rolls, " + ")
+	if modifier != 0 {
+		label += fmt.Sprintf("%+d", modifier)
+		result += fmt.Sprintf(" (%+d)", modifier)
+	}
+	return fmt.Sprintf(`<b class="dice" title="Rolled by the server">&#x1F3B2; %s: %s = %d</b>`, label, result, total), nil
+}
+
+func (d *Dice) Post(db *sriracha.Database, post *sriracha.Post) error {
+	var found bool
+	for _, boardID := range d.boards {
+		if boardID == post.Board.ID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	var patterns []*regexp.Regexp
+	if d.syntax == syntaxHash || d.syntax == syntaxBoth {
+		patterns = append(patterns, hashPattern)
+	}
+	if d.syntax == syntaxBBCode || d.syntax == syntaxBoth {
+		patterns = append(patterns, bbcodePattern)
+	}
+
+	var rolls int
+	var err error
+	for _, pattern := range patterns {
+		post.Message = pattern.ReplaceAllStringFunc(post.Message, func(s string) string {
+			if err != nil || (d.maxRolls > 0 && rolls >= d.maxRolls) {
+				return s
+			}
+			match := pattern.FindStringSubmatch(s)
+			if match[2] == "" {
+				if d.dice == nil {
+					return s
+				}
+				match = d.dice
+			}
+			var result string
+			result, err = d.roll(match)
+			if err != nil {
+				return s
+			}
+			rolls++
+			return result
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	sriracha.RegisterPlugin(&Dice{})
+}
+
+func main() {}
+
+// Validate plugin interfaces during compilation.
+var (
+	_ sriracha.Plugin           = &Dice{}
+	_ sriracha.PluginWithConfig = &Dice{}
+	_ sriracha.PluginWithUpdate = &Dice{}
+	_ sriracha.PluginWithPost   = &Dice{}
+)
@@ -11,7 +11,9 @@ import (
 	"io/fs"
 	"log"
 	"math"
+	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
 	"os/signal"
@@ -30,6 +32,7 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/leonelquinteros/gotext"
+	"github.com/oschwald/maxminddb-golang"
 	"github.com/r3labs/diff/v3"
 	"golang.org/x/exp/constraints"
 	"golang.org/x/sys/unix"
@@ -51,8 +54,25 @@ const (
 	defaultServerOekakiWidth  = 540
 	defaultServerOekakiHeight = 540
 	defaultServerRefresh      = 30
+
+	defaultServerCAPTCHADifficulty = 16
+
+	defaultServerBanMessage      = "(USER WAS BANNED FOR THIS POST)"
+	defaultServerBanMessageStyle = "color: red; font-weight: bold;"
+
+	defaultServerFloodWindow   = 60
+	defaultServerFloodDuration = 600
+
+	defaultServerSessionIdle   = 72
+	defaultServerSessionExpire = 720
 )
 
+var defaultServerReportCategories = []string{
+	"Spam",
+	"Rule violation",
+	"Illegal content",
+}
+
 var defaultServerEmbeds = [][2]string{
 	{"YouTube", "https://youtube.com/oembed?format=json&url=SRIRACHA_EMBED"},
 	{"Vimeo", "https://vimeo.com/api/oembed.json?url=SRIRACHA_EMBED"},
@@ -83,29 +103,57 @@ const (
 )
 
 type ServerOptions struct {
-	SiteName         string
-	SiteHome         string
-	News             NewsOption
-	BoardIndex       bool
-	CAPTCHA          bool
-	Refresh          int
-	Uploads          []*uploadType
-	Embeds           [][2]string
-	OekakiWidth      int
-	OekakiHeight     int
-	Overboard        string
-	OverboardType    BoardType
-	OverboardThreads int
-	OverboardReplies int
+	SiteName          string
+	SiteHome          string
+	News              NewsOption
+	BoardIndex        bool
+	CAPTCHADifficulty int
+	Refresh           int
+	Uploads           []*uploadType
+	Embeds            [][2]string
+	OekakiWidth       int
+	OekakiHeight      int
+	Overboard         string
+	OverboardType     BoardType
+	OverboardThreads  int
+	OverboardReplies  int
+	BanMessage        string
+	BanMessageStyle   string
+	FloodWindow       int
+	FloodPosts        int
+	FloodThreads      int
+	FloodDuplicates   int
+	FloodAction       FloodAction
+	FloodDuration     int
+	Require2FA        []AccountRole
+	SessionIdle       int
+	SessionExpire     int
+	ReportCategories  []string
+}
+
+// Requires2FA returns whether accounts with the specified built-in role must
+// enable two-factor authentication.
+func (opt *ServerOptions) Requires2FA(role AccountRole) bool {
+	for _, r := range opt.Require2FA {
+		if r == role {
+			return true
+		}
+	}
+	return false
 }
 
 type Server struct {
 	Boards []*Board
 
 	rangeBans map[*Ban]*regexp.Regexp
+	cidrBans  map[*Ban]netip.Prefix
+	nameBans  map[*Ban]*regexp.Regexp
+	keywords  *keywordFilter
+	flood     floodDetector
 
 	config Config
 	dbPool *pgxpool.Pool
+	geoIP  *maxminddb.Reader
 	opt    ServerOptions
 	tpl    *template.Template
 	lock   sync.Mutex
@@ -229,7 +277,11 @@ func (s *Server) setDefaultServerConfig() error {
 	boardIndex := db.GetString("boardindex")
 	s.opt.BoardIndex = boardIndex == "" || boardIndex == "1"
 
-	s.opt.CAPTCHA = db.GetBool("captcha")
+	captchaDifficulty := db.GetInt("captchadifficulty")
+	if captchaDifficulty <= 0 || captchaDifficulty > maxCAPTCHADifficulty {
+		captchaDifficulty = defaultServerCAPTCHADifficulty
+	}
+	s.opt.CAPTCHADifficulty = captchaDifficulty
 
 	oekakiWidth := db.GetInt("oekakiwidth")
 	if oekakiWidth == 0 {
@@ -254,6 +306,63 @@ func (s *Server) setDefaultServerConfig() error {
 	s.opt.OverboardThreads = db.GetInt("overboardthreads")
 	s.opt.OverboardReplies = db.GetInt("overboardreplies")
 
+	banMessage := db.GetString("banmessage")
+	if banMessage == "" {
+		banMessage = defaultServerBanMessage
+	}
+	s.opt.BanMessage = banMessage
+
+	banMessageStyle := db.GetString("banmessagestyle")
+	if banMessageStyle == "" {
+		banMessageStyle = defaultServerBanMessageStyle
+	}
+	s.opt.BanMessageStyle = banMessageStyle
+
+	floodWindow := db.GetInt("floodwindow")
+	if floodWindow == 0 {
+		floodWindow = defaultServerFloodWindow
+	}
+	s.opt.FloodWindow = floodWindow
+
+	s.opt.FloodPosts = db.GetInt("floodposts")
+	s.opt.FloodThreads = db.GetInt("floodthreads")
+	s.opt.FloodDuplicates = db.GetInt("floodduplicates")
+	s.opt.FloodAction = FloodAction(db.GetInt("floodaction"))
+
+	floodDuration := db.GetInt("floodduration")
+	if floodDuration == 0 {
+		floodDuration = defaultServerFloodDuration
+	}
+	s.opt.FloodDuration = floodDuration
+
+	if !db.HaveConfig("sessionidle") {
+		s.opt.SessionIdle = defaultServerSessionIdle
+	} else {
+		s.opt.SessionIdle = db.GetInt("sessionidle")
+	}
+
+	if !db.HaveConfig("sessionexpire") {
+		s.opt.SessionExpire = defaultServerSessionExpire
+	} else {
+		s.opt.SessionExpire = db.GetInt("sessionexpire")
+	}
+
+	s.opt.ReportCategories = nil
+	if !db.HaveConfig("reportcategories") {
+		s.opt.ReportCategories = append(s.opt.ReportCategories, defaultServerReportCategories...)
+	} else {
+		for _, category := range db.GetMultiString("reportcategories") {
+			if category != "" {
+				s.opt.ReportCategories = append(s.opt.ReportCategories, category)
+			}
+		}
+	}
+
+	s.opt.Require2FA = nil
+	for _, role := range db.GetMultiInt("require2fa") {
+		s.opt.Require2FA = append(s.opt.Require2FA, AccountRole(role))
+	}
+
 	s.opt.Uploads = s.config.UploadTypes()
 
 	s.opt.Embeds = nil
@@ -271,6 +380,7 @@ func (s *Server) setDefaultServerConfig() error {
 	}
 
 	s.reloadBans(db)
+	s.reloadKeywords(db)
 
 	_, err = conn.Exec(context.Background(), "COMMIT")
 	if err != nil {
@@ -421,6 +531,7 @@ func (s *Server) deletePostFiles(p *Post) {
 		return
 	} else if p.ID != 0 && p.Parent == 0 {
 		os.Remove(filepath.Join(s.config.Root, p.Board.Dir, "res", fmt.Sprintf("%d.html", p.ID)))
+		s.removeThreadPages(p.Board, p.ID, 1)
 	}
 
 	if p.File == "" {
@@ -447,44 +558,41 @@ func (s *Server) deletePost(db *Database, p *Post) {
 
 func (s *Server) buildData(db *Database, w http.ResponseWriter, r *http.Request) *templateData {
 	if strings.HasPrefix(r.URL.Path, "/sriracha/logout") {
-		http.SetCookie(w, &http.Cookie{
-			Name:  "sriracha_session",
-			Value: "",
-			Path:  "/",
-		})
+		cookies := r.CookiesNamed("sriracha_session")
+		if len(cookies) > 0 {
+			session := db.sessionByKey(cookies[0].Value)
+			if session != nil {
+				db.deleteSession(session.ID)
+			}
+		}
+		s.setSessionCookie(w, "")
 		http.Redirect(w, r, "/sriracha/", http.StatusFound)
 		return newTemplateData()
 	}
 
 	if r.URL.Path == "/sriracha/" || r.URL.Path == "/sriracha" {
-		var failedLogin bool
+		var failedLogin string
 		username := r.FormValue("username")
 		if len(username) != 0 {
-			failedLogin = true
-			password := r.FormValue("password")
-			if len(password) != 0 {
-				account := db.loginAccount(username, password)
-				if account != nil {
-					http.SetCookie(w, &http.Cookie{
-						Name:  "sriracha_session",
-						Value: account.Session,
-						Path:  "/",
-					})
-					if s.config.importMode {
-						http.Redirect(w, r, "/sriracha/import/", http.StatusFound)
-					}
-					return &templateData{
-						Account: account,
-						Manage: &manageData{
-							Plugins: allPluginInfo,
-						},
-					}
+			account, message := s.login(db, r, username, r.FormValue("password"))
+			if account != nil {
+				s.setSessionCookie(w, account.Session)
+				if s.config.importMode {
+					http.Redirect(w, r, "/sriracha/import/", http.StatusFound)
+				}
+				return &templateData{
+					Account:  account,
+					loggedIn: true,
+					Manage: &manageData{
+						Plugins: allPluginInfo,
+					},
 				}
 			}
+			failedLogin = message
 		}
-		if failedLogin {
+		if failedLogin != "" {
 			return &templateData{
-				Info:     "Invalid username or password.",
+				Info:     failedLogin,
 				Template: "manage_error",
 				Manage: &manageData{
 					Plugins: allPluginInfo,
@@ -508,30 +616,106 @@ func (s *Server) buildData(db *Database, w http.ResponseWriter, r *http.Request)
 	return newTemplateData()
 }
 
+// setSessionCookie sets the session cookie. An empty value removes the cookie.
+func (s *Server) setSessionCookie(w http.ResponseWriter, value string) {
+	cookie := &http.Cookie{
+		Name:     "sriracha_session",
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   s.config.Secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if value == "" {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(w, cookie)
+}
+
 func (s *Server) writeThread(db *Database, board *Board, postID int) {
+	s.writeThreadPages(db, board, postID, 0)
+}
+
+// writeThreadPages writes the pages of a thread, starting with firstPage.
+func (s *Server) writeThreadPages(db *Database, board *Board, postID int, firstPage int) {
 	posts := db.AllPostsInThread(postID, true)
 	if len(posts) == 0 {
 		return
 	}
+	if board.Type == TypeForum {
+		posts[0].Poll = db.pollByPost(posts[0].ID)
+	}
 
 	if board.Unique == 0 {
 		board.Unique = db.UniqueUserPosts(board)
 	}
 
-	f, err := os.OpenFile(filepath.Join(s.config.Root, board.Dir, "res", fmt.Sprintf("%d.html", postID)), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-	if err != nil {
-		log.Fatal(err)
-	}
+	perPage, pages := paginateThread(board, posts)
 
 	data := &templateData{
 		Board:     board,
 		Boards:    db.AllBoards(),
-		Threads:   [][]*Post{posts},
+		Pages:     pages,
+		Post:      posts[0],
 		ReplyMode: postID,
 		Manage:    &manageData{},
 		Template:  "board_page",
 	}
-	data.execute(f)
+	for page := firstPage; page < pages; page++ {
+		f, err := os.OpenFile(filepath.Join(s.config.Root, board.Dir, "res", posts[page*perPage].ThreadFile()), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		end := min(page*perPage+perPage, len(posts))
+		data.Threads = [][]*Post{posts[page*perPage : end]}
+		data.Page = page
+		data.execute(f)
+
+		f.Close()
+	}
+
+	s.removeThreadPages(board, postID, pages)
+}
+
+// removeThreadPages removes thread pages starting with firstPage.
+func (s *Server) removeThreadPages(board *Board, threadID int, firstPage int) {
+	for page := max(firstPage, 1); ; page++ {
+		err := os.Remove(filepath.Join(s.config.Root, board.Dir, "res", fmt.Sprintf("%d-%d.html", threadID, page+1)))
+		if err != nil {
+			return
+		}
+	}
+}
+
+// paginateThread sets the page of each post in a thread and returns the
+// number of posts per page and the number of pages.
+func paginateThread(board *Board, posts []*Post) (int, int) {
+	if !board.paginateThreads() || len(posts) == 0 {
+		return len(posts), 1
+	}
+	for i, post := range posts {
+		post.Page = i / board.PostsPerPage
+	}
+	return board.PostsPerPage, pageCount(len(posts), board.PostsPerPage)
+}
+
+// postLink returns a link to a post which remains valid when the pages of its
+// thread change. Forum threads may be paginated, so their posts are linked via
+// /sriracha/post/, which redirects to the page which contains the post.
+func postLink(p *Post) string {
+	if p.Board.Type == TypeForum {
+		return fmt.Sprintf("/sriracha/post/%d#%d", p.ID, p.ID)
+	}
+	return fmt.Sprintf("%sres/%d.html#%d", p.Board.Path(), p.Thread(), p.ID)
+}
+
+// postURL returns the URL of the thread page which contains the post.
+func postURL(db *Database, p *Post) string {
+	if p.Board.paginateThreads() {
+		p.Page = db.postIndex(p) / p.Board.PostsPerPage
+	}
+	return fmt.Sprintf("%sres/%s#%d", p.Board.Path(), p.ThreadFile(), p.ID)
 }
 
 func (s *Server) writeIndexes(db *Database, board *Board) {
@@ -685,7 +869,13 @@ func (s *Server) writeOverboard(db *Database) {
 }
 
 func (s *Server) rebuildThread(db *Database, post *Post) {
-	s.writeThread(db, post.Board, post.Thread())
+	s.rebuildThreadPages(db, post, 0)
+}
+
+// rebuildThreadPages rebuilds the pages of a thread starting with firstPage,
+// as well as the board indexes.
+func (s *Server) rebuildThreadPages(db *Database, post *Post, firstPage int) {
+	s.writeThreadPages(db, post.Board, post.Thread(), firstPage)
 	s.writeIndexes(db, post.Board)
 	if s.opt.Overboard != "" {
 		s.writeOverboard(db)
@@ -785,8 +975,27 @@ func (s *Server) rebuildNews(db *Database) {
 
 func (s *Server) reloadBans(db *Database) {
 	var rangeBans = make(map[*Ban]*regexp.Regexp)
+	var cidrBans = make(map[*Ban]netip.Prefix)
+	var nameBans = make(map[*Ban]*regexp.Regexp)
 	bans := db.allBans(true)
 	for _, ban := range bans {
+		if ban.IsName() {
+			pattern, err := regexp.Compile(ban.IP[2:])
+			if err != nil {
+				log.Printf("warning: failed to compile name ban `%s` as regular expression: %s", ban.IP[2:], err)
+				continue
+			}
+			nameBans[ban] = pattern
+			continue
+		} else if ban.Range != "" {
+			prefix, err := netip.ParsePrefix(ban.Range)
+			if err != nil {
+				log.Printf("warning: failed to parse IP range ban `%s`: %s", ban.Range, err)
+				continue
+			}
+			cidrBans[ban] = prefix
+			continue
+		}
 		pattern, err := regexp.Compile(ban.IP[2:])
 		if err != nil {
 			log.Printf("warning: failed to compile IP range ban `%s` as regular expression: %s", ban.IP[2:], err)
@@ -795,6 +1004,134 @@ func (s *Server) reloadBans(db *Database) {
 		rangeBans[ban] = pattern
 	}
 	s.rangeBans = rangeBans
+	s.cidrBans = cidrBans
+	s.nameBans = nameBans
+}
+
+// requestBans returns all bans matching the IP address of a request.
+func (s *Server) requestBans(db *Database, r *http.Request) []*Ban {
+	var bans []*Ban
+
+	ip := requestIP(r)
+	addr, err := netip.ParseAddr(ip)
+	if err == nil {
+		addr = addr.Unmap()
+		for ban, prefix := range s.cidrBans {
+			if prefix.Contains(addr) {
+				bans = append(bans, ban)
+			}
+		}
+	}
+	for ban, pattern := range s.rangeBans {
+		if pattern.MatchString(ip) {
+			bans = append(bans, ban)
+		}
+	}
+
+	ban := db.banByIP(hashIP(r))
+	if ban != nil {
+		bans = append(bans, ban)
+	}
+	return bans
+}
+
+// postBans returns all bans matching the tripcode, file or name of a post.
+func (s *Server) postBans(db *Database, post *Post) []*Ban {
+	var bans []*Ban
+	if post.Tripcode != "" {
+		ban := db.banByIP("t " + post.Tripcode)
+		if ban != nil {
+			bans = append(bans, ban)
+		}
+	}
+	if post.FileHash != "" {
+		ban := db.banByIP("f " + post.FileHash)
+		if ban != nil {
+			bans = append(bans, ban)
+		}
+	}
+	if post.Name != "" {
+		for ban, pattern := range s.nameBans {
+			if pattern.MatchString(post.Name) {
+				bans = append(bans, ban)
+			}
+		}
+	}
+	return bans
+}
+
+// checkPostingBan returns true and shows the ban when the request is banned
+// from posting and reporting in the specified board.
+func (s *Server) checkPostingBan(db *Database, w http.ResponseWriter, r *http.Request, board *Board) bool {
+	for _, ban := range s.requestBans(db, r) {
+		if ban.Type != BanWarning && ban.Type != BanReport && ban.AppliesTo(board) {
+			s.serveBanned(db, w, r, ban)
+			return true
+		}
+	}
+	return false
+}
+
+// checkReportingBan returns true and shows the ban when the request is banned
+// from reporting in the specified board.
+func (s *Server) checkReportingBan(db *Database, w http.ResponseWriter, r *http.Request, board *Board) bool {
+	for _, ban := range s.requestBans(db, r) {
+		if ban.Type != BanWarning && ban.AppliesTo(board) {
+			s.serveBanned(db, w, r, ban)
+			return true
+		}
+	}
+	return false
+}
+
+// checkPostBan returns true and shows the ban when the tripcode, file or name
+// of a post is banned in the board the post was submitted to.
+func (s *Server) checkPostBan(db *Database, w http.ResponseWriter, r *http.Request, post *Post) bool {
+	for _, ban := range s.postBans(db, post) {
+		if ban.Type != BanWarning && ban.Type != BanReport && ban.AppliesTo(post.Board) {
+			s.serveBanned(db, w, r, ban)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) serveBanned(db *Database, w http.ResponseWriter, r *http.Request, ban *Ban) {
+	var label string
+	switch ban.Type {
+	case BanWarning:
+		label = "You have received a warning."
+	case BanPost:
+		label = "You are banned from posting."
+	case BanReport:
+		label = "You are banned from reporting."
+	default:
+		label = "You are banned."
+	}
+	if len(ban.Boards) != 0 {
+		var boards []string
+		for _, b := range ban.Boards {
+			boards = append(boards, b.Path())
+		}
+		label += " This ban applies to " + strings.Join(boards, ", ") + "."
+	}
+	if info := ban.Info(); info != "" {
+		label += " " + info
+	}
+	if ban.Type != BanWarning {
+		label += fmt.Sprintf(" (Ban #%d)", ban.ID)
+	}
+
+	data := s.buildData(db, w, r)
+	data.Template = "manage_banned"
+	data.Info = label
+	data.Manage.Ban = ban
+	if ban.Type == BanWarning {
+		data.Extra = r.URL.RequestURI()
+	} else if ban.IsAddress() || ban.IsRange() {
+		data.Manage.Appeal = db.appealByBan(ban)
+	}
+	data.execute(w)
 }
 
 func (s *Server) serveSWF(w http.ResponseWriter, r *http.Request) {
@@ -853,9 +1190,18 @@ func (s *Server) serveManage(db *Database, w http.ResponseWriter, r *http.Reques
 	if data.Account == nil {
 		data.execute(w)
 		return
+	} else if r.Method == http.MethodPost && !data.loggedIn && !data.validCSRF(r) {
+		data.ManageError("Invalid form token. Reload the page and try again.")
+		data.execute(w)
+		return
+	} else if data.Account.Restricted() {
+		if !strings.HasPrefix(r.URL.Path, "/sriracha/preference") {
+			http.Redirect(w, r, "/sriracha/preference/", http.StatusFound)
+			return
+		}
 	} else if s.config.importMode {
-		if data.Account.Role != RoleSuperAdmin {
-			data.ManageError("Sriracha is running in import mode. Only super-administrators may log in.")
+		if !data.Account.Can(PermissionImport) {
+			data.ManageError("Sriracha is running in import mode. Only accounts with permission to import boards may log in.")
 			data.execute(w)
 			return
 		} else if !strings.HasPrefix(r.URL.Path, "/sriracha/import/") {
@@ -886,6 +1232,8 @@ func (s *Server) serveManage(db *Database, w http.ResponseWriter, r *http.Reques
 		s.serveNews(data, db, w, r)
 	case strings.HasPrefix(r.URL.Path, "/sriracha/plugin"):
 		s.servePlugin(data, db, w, r)
+	case strings.HasPrefix(r.URL.Path, "/sriracha/role"):
+		s.serveRole(data, db, w, r)
 	case strings.HasPrefix(r.URL.Path, "/sriracha/setting"):
 		s.serveSetting(data, db, w, r)
 	default:
@@ -950,38 +1298,45 @@ func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
 	if db.deleteExpiredBans() > 0 {
 		s.reloadBans(db)
 	}
+	s.restoreFloodBoards(db)
 
-	// Check IP range ban.
-	ip := requestIP(r)
-	for ban, pattern := range s.rangeBans {
-		if pattern.MatchString(ip) {
-			data := s.buildData(db, w, r)
-			data.ManageError("You are banned. " + ban.Info() + fmt.Sprintf(" (Ban #%d)", ban.ID))
-			data.execute(w)
+	// Check IP bans. Bans which only apply to posting or to specific boards
+	// are checked when posting. Warnings are shown on the next page viewed so
+	// that submitted forms are not discarded.
+	for _, ban := range s.requestBans(db, r) {
+		if ban.Type == BanWarning {
+			if r.Method != http.MethodGet {
+				continue
+			}
+			s.serveBanned(db, w, r, ban)
+
+			db.deleteBan(ban.ID)
+			db.log(nil, nil, fmt.Sprintf("Displayed warning >>/ban/%d", ban.ID), "")
+
+			handled = true
+			break
+		} else if ban.Type == BanAll && len(ban.Boards) == 0 && action != "appeal" {
+			s.serveBanned(db, w, r, ban)
 			handled = true
 			break
 		}
 	}
 
-	// Check static IP ban.
-	if !handled {
-		ban := db.banByIP(hashIP(r))
-		if ban != nil {
+	if !handled && strings.HasPrefix(r.URL.Path, "/sriracha/api/") {
+		s.serveAPI(db, w, r)
+		handled = true
+	}
+
+	if !handled && strings.HasPrefix(r.URL.Path, "/sriracha/post/") {
+		postID := pathInt(r, "/sriracha/post/")
+		post := db.PostByID(postID)
+		if post == nil {
 			data := s.buildData(db, w, r)
-			data.ManageError("You are banned. " + ban.Info() + fmt.Sprintf(" (Ban #%d)", ban.ID))
-			data.execute(w)
-			handled = true
-		} else if strings.HasPrefix(r.URL.Path, "/sriracha/post/") {
-			postID := pathInt(r, "/sriracha/post/")
-			post := db.PostByID(postID)
-			if post == nil {
-				data := s.buildData(db, w, r)
-				data.BoardError(w, "Invalid or deleted post.")
-			} else {
-				http.Redirect(w, r, fmt.Sprintf("%sres/%d.html#%d", post.Board.Path(), post.Thread(), post.ID), http.StatusFound)
-			}
-			handled = true
+			data.BoardError(w, "Invalid or deleted post.")
+		} else {
+			http.Redirect(w, r, postURL(db, post), http.StatusFound)
 		}
+		handled = true
 	}
 
 	if !handled {
@@ -994,8 +1349,12 @@ func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
 				s.servePost(db, w, r)
 			case "report":
 				s.serveReport(db, w, r)
+			case "appeal":
+				s.serveAppeal(db, w, r)
 			case "delete":
 				s.serveDelete(db, w, r)
+			case "vote":
+				s.serveVote(db, w, r)
 			case "captcha":
 				s.serveCAPTCHA(db, w, r)
 			default:
@@ -1089,6 +1448,13 @@ func (s *Server) Run() error {
 		fmt.Println("Running in development mode. Template files are monitored for changes.")
 	}
 
+	if s.config.GeoIP != "" {
+		s.geoIP, err = maxminddb.Open(s.config.GeoIP)
+		if err != nil {
+			return fmt.Errorf("failed to open GeoIP database %s: %s", s.config.GeoIP, err)
+		}
+	}
+
 	s.dbPool, err = connectDatabase(s.config)
 	if err != nil {
 		return err
@@ -1196,6 +1562,34 @@ func requestIP(r *http.Request) string {
 	return parseAddress(address)
 }
 
+func (s *Server) lookupCountry(address string) string {
+	if s.geoIP == nil {
+		return ""
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return ""
+	}
+	var record struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+	}
+	err := s.geoIP.Lookup(ip, &record)
+	if err != nil || len(record.Country.ISOCode) != 2 {
+		return ""
+	}
+	return strings.ToUpper(record.Country.ISOCode)
+}
+
+func countryFlag(code string) string {
+	if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return ""
+	}
+	const regionalIndicatorA = 0x1F1E6
+	return string([]rune{regionalIndicatorA + rune(code[0]-'A'), regionalIndicatorA + rune(code[1]-'A')})
+}
+
 func hashIP(r *http.Request) string {
 	return _hashIP(requestIP(r))
 }
@@ -1345,12 +1739,18 @@ func FormatFileSize(size int64) string {
 func formatValue(v interface{}) interface{} {
 	if role, ok := v.(AccountRole); ok {
 		return formatRole(role)
+	} else if p, ok := v.(Permission); ok {
+		return formatPermission(p)
 	} else if t, ok := v.(BoardType); ok {
 		return formatBoardType(t)
 	} else if t, ok := v.(BoardLock); ok {
 		return formatBoardLock(t)
 	} else if t, ok := v.(BoardApproval); ok {
 		return formatBoardApproval(t)
+	} else if t, ok := v.(BanType); ok {
+		return formatBanType(t)
+	} else if a, ok := v.(FloodAction); ok {
+		return formatFloodAction(a)
 	}
 	return v
 }
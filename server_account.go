@@ -7,10 +7,12 @@ import (
 )
 
 func (s *Server) serveAccount(data *templateData, db *Database, w http.ResponseWriter, r *http.Request) {
-	if data.forbidden(w, RoleSuperAdmin) {
+	if data.forbidden(w, PermissionAccount) {
 		return
 	}
 	data.Template = "manage_account"
+	data.Boards = db.AllBoards()
+	data.Manage.Roles = db.allRoles()
 
 	accountID := pathInt(r, "/sriracha/account/")
 	if accountID > 0 {
@@ -19,19 +21,33 @@ func (s *Server) serveAccount(data *templateData, db *Database, w http.ResponseW
 		if data.Manage.Account != nil && r.Method == http.MethodPost {
 			oldAccount := *data.Manage.Account
 			oldUsername := data.Manage.Account.Username
-			data.Manage.Account.loadForm(r)
+			data.Manage.Account.loadForm(db, r)
 
 			err := data.Manage.Account.validate()
 			if err != nil {
 				data.ManageError(err.Error())
 				return
+			} else if !s.mayAssignRole(data, db, &oldAccount, data.Manage.Account) {
+				return
 			}
 
-			if data.Account.ID == data.Manage.Account.ID && data.Manage.Account.Role != RoleSuperAdmin {
+			if data.Account.ID == data.Manage.Account.ID && (data.Manage.Account.Role != oldAccount.Role || data.Manage.Account.CustomRole != oldAccount.CustomRole) {
 				data.ManageError("You may not change the role of your own account.")
 				return
 			}
 
+			reset2FA := formBool(r, "reset2fa") && data.Manage.Account.TwoFactor()
+			if reset2FA && data.Account.Role != RoleSuperAdmin {
+				data.ManageError("Only super-administrators may reset two-factor authentication.")
+				return
+			}
+
+			logout := formBool(r, "logout")
+			if logout && data.Account.Role != RoleSuperAdmin {
+				data.ManageError("Only super-administrators may log out accounts.")
+				return
+			}
+
 			if data.Manage.Account.Username != oldUsername {
 				match := db.accountByUsername(data.Manage.Account.Username)
 				if match != nil {
@@ -43,12 +59,23 @@ func (s *Server) serveAccount(data *templateData, db *Database, w http.ResponseW
 			}
 
 			db.updateAccountRole(data.Manage.Account)
+			db.updateAccountBoards(data.Manage.Account)
 
 			password := r.FormValue("password")
 			if strings.TrimSpace(password) != "" {
 				db.updateAccountPassword(data.Manage.Account.ID, password)
 			}
 
+			if reset2FA {
+				db.updateAccountTOTP(data.Manage.Account.ID, "", "")
+				db.log(data.Account, nil, fmt.Sprintf("Reset two-factor authentication of >>/account/%d", data.Manage.Account.ID), "")
+			}
+
+			if logout {
+				deleted := db.deleteAccountSessions(data.Manage.Account.ID, data.Account.Session)
+				db.log(data.Account, nil, fmt.Sprintf("Logged out >>/account/%d", data.Manage.Account.ID), fmt.Sprintf("%d sessions ended", deleted))
+			}
+
 			changes := printChanges(oldAccount, *data.Manage.Account)
 			db.log(data.Account, nil, fmt.Sprintf("Updated >>/account/%d", data.Manage.Account.ID), changes)
 
@@ -60,12 +87,14 @@ func (s *Server) serveAccount(data *templateData, db *Database, w http.ResponseW
 
 	if r.Method == http.MethodPost {
 		a := &Account{}
-		a.loadForm(r)
+		a.loadForm(db, r)
 
 		err := a.validate()
 		if err != nil {
 			data.ManageError(err.Error())
 			return
+		} else if !s.mayAssignRole(data, db, a, a) {
+			return
 		}
 
 		password := r.FormValue("password")
@@ -84,3 +113,24 @@ func (s *Server) serveAccount(data *templateData, db *Database, w http.ResponseW
 
 	data.Manage.Accounts = db.allAccounts()
 }
+
+// mayAssignRole returns whether the role of an account may be assigned by the
+// current account. Only super-administrators may manage super-administrator
+// accounts. Other accounts may only manage accounts whose permissions they
+// also have, both before and after the update.
+func (s *Server) mayAssignRole(data *templateData, db *Database, old *Account, a *Account) bool {
+	if (old.Role == RoleSuperAdmin || a.Role == RoleSuperAdmin) && data.Account.Role != RoleSuperAdmin {
+		data.ManageError("Only super-administrators may manage super-administrator accounts.")
+		return false
+	} else if a.Role == RoleCustom && db.roleByID(a.CustomRole) == nil {
+		data.ManageError("Invalid custom role.")
+		return false
+	}
+	oldPermissions := old.Permissions
+	db.fetchAccountRole(a)
+	if data.Account.Role != RoleSuperAdmin && (!data.Account.CanAll(oldPermissions) || !data.Account.CanAll(a.Permissions)) {
+		data.ManageError("You may only manage accounts whose permissions you also have.")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,315 @@
+package sriracha
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type apiPost struct {
+	ID           int    `json:"id"`
+	Board        string `json:"board"`
+	Parent       int    `json:"parent"`
+	Timestamp    int64  `json:"timestamp"`
+	Name         string `json:"name"`
+	Tripcode     string `json:"tripcode"`
+	Email        string `json:"email"`
+	Subject      string `json:"subject"`
+	Message      string `json:"message"`
+	File         string `json:"file"`
+	FileHash     string `json:"filehash"`
+	Moderated    int    `json:"moderated"`
+	Stickied     bool   `json:"stickied"`
+	Locked       bool   `json:"locked"`
+	BanMessage   string `json:"banmessage"`
+	ReportHidden bool   `json:"reporthidden"`
+}
+
+func newAPIPost(p *Post) *apiPost {
+	post := &apiPost{
+		ID:           p.ID,
+		Parent:       p.Parent,
+		Timestamp:    p.Timestamp,
+		Name:         p.Name,
+		Tripcode:     p.Tripcode,
+		Email:        p.Email,
+		Subject:      p.Subject,
+		Message:      p.Message,
+		FileHash:     p.FileHash,
+		Moderated:    int(p.Moderated),
+		Stickied:     p.Stickied,
+		Locked:       p.Locked,
+		BanMessage:   p.BanMessage,
+		ReportHidden: p.ReportHidden,
+	}
+	if p.Board != nil {
+		post.Board = p.Board.Dir
+		if p.File != "" && !p.IsEmbed() {
+			post.File = p.Board.Path() + "src/" + p.File
+		}
+	}
+	return post
+}
+
+type apiReport struct {
+	Post       *apiPost         `json:"post"`
+	Count      int              `json:"count"`
+	Categories map[string]int   `json:"categories"`
+	Reports    []*apiReportItem `json:"reports"`
+}
+
+type apiReportItem struct {
+	ID        int    `json:"id"`
+	Category  string `json:"category"`
+	Comment   string `json:"comment"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func newAPIReport(r *Report) *apiReport {
+	report := &apiReport{
+		Post:       newAPIPost(r.Post),
+		Count:      r.Count(),
+		Categories: make(map[string]int),
+		Reports:    []*apiReportItem{},
+	}
+	for _, c := range r.CategoryCounts() {
+		report.Categories[c.Category] = c.Count
+	}
+	for _, item := range r.Reports() {
+		report.Reports = append(report.Reports, &apiReportItem{
+			ID:        item.ID,
+			Category:  item.Category,
+			Comment:   item.Comment,
+			Timestamp: item.Timestamp,
+		})
+	}
+	return report
+}
+
+type apiBan struct {
+	ID        int      `json:"id"`
+	Subject   string   `json:"subject"`
+	Type      int      `json:"type"`
+	Timestamp int64    `json:"timestamp"`
+	Expire    int64    `json:"expire"`
+	Reason    string   `json:"reason"`
+	Boards    []string `json:"boards"`
+}
+
+func newAPIBan(b *Ban) *apiBan {
+	ban := &apiBan{
+		ID:        b.ID,
+		Subject:   b.TypeLabel(),
+		Type:      int(b.Type),
+		Timestamp: b.Timestamp,
+		Expire:    b.Expire,
+		Reason:    b.Reason,
+		Boards:    []string{},
+	}
+	for _, board := range b.Boards {
+		ban.Boards = append(ban.Boards, board.Dir)
+	}
+	return ban
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		log.Fatal(err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(buf)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// serveAPI serves the JSON management API. Requests are authenticated using
+// API tokens, which are sent in the Authorization header.
+func (s *Server) serveAPI(db *Database, w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		writeAPIError(w, http.StatusUnauthorized, "API token required")
+		return
+	}
+	a := db.accountByAPIToken(strings.TrimSpace(token))
+	if a == nil {
+		writeAPIError(w, http.StatusUnauthorized, "invalid API token")
+		return
+	} else if a.Restricted() {
+		writeAPIError(w, http.StatusForbidden, "account preferences must be updated")
+		return
+	} else if s.config.importMode {
+		writeAPIError(w, http.StatusServiceUnavailable, "Sriracha is running in import mode")
+		return
+	}
+	db.updateAccountLastActive(a.ID)
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/sriracha/api/"), "/")
+	split := strings.Split(path, "/")
+	id := parseInt(split[len(split)-1])
+
+	method := http.MethodPost
+	switch path {
+	case "reports", "pending", "bans":
+		method = http.MethodGet
+	}
+	if r.Method != method {
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	switch {
+	case path == "reports":
+		if !a.Can(PermissionApprove) {
+			writeAPIError(w, http.StatusForbidden, "access forbidden")
+			return
+		}
+		reports := []*apiReport{}
+		for _, report := range db.allReports() {
+			if report.Post != nil && a.Moderates(report.Post.Board) {
+				reports = append(reports, newAPIReport(report))
+			}
+		}
+		writeJSON(w, http.StatusOK, reports)
+	case path == "pending":
+		if !a.Can(PermissionApprove) {
+			writeAPIError(w, http.StatusForbidden, "access forbidden")
+			return
+		}
+		posts := []*apiPost{}
+		for _, post := range db.pendingPosts() {
+			if a.Moderates(post.Board) {
+				posts = append(posts, newAPIPost(post))
+			}
+		}
+		writeJSON(w, http.StatusOK, posts)
+	case path == "bans":
+		if !a.Can(PermissionBan) && !a.Can(PermissionLiftBan) {
+			writeAPIError(w, http.StatusForbidden, "access forbidden")
+			return
+		}
+		bans := []*apiBan{}
+		for _, ban := range db.allBans(false) {
+			if a.ModeratesBan(ban) {
+				bans = append(bans, newAPIBan(ban))
+			}
+		}
+		writeJSON(w, http.StatusOK, bans)
+	case len(split) == 3 && split[0] == "ban" && split[1] == "lift":
+		if !a.Can(PermissionLiftBan) {
+			writeAPIError(w, http.StatusForbidden, "access forbidden")
+			return
+		}
+		b := db.banByID(id)
+		if b == nil || !a.ModeratesBan(b) {
+			writeAPIError(w, http.StatusNotFound, "unknown ban")
+			return
+		}
+		s.liftBan(db, a, b, formString(r, "reason"))
+		writeJSON(w, http.StatusOK, newAPIBan(b))
+	case len(split) == 2 && split[0] == "approve":
+		if !a.Can(PermissionApprove) {
+			writeAPIError(w, http.StatusForbidden, "access forbidden")
+			return
+		}
+		post := db.PostByID(id)
+		if post == nil || !a.Moderates(post.Board) {
+			writeAPIError(w, http.StatusNotFound, "unknown post")
+			return
+		}
+		s.approvePost(db, a, post)
+		writeJSON(w, http.StatusOK, newAPIPost(db.PostByID(id)))
+	case len(split) == 2 && split[0] == "dismiss":
+		if !a.Can(PermissionApprove) {
+			writeAPIError(w, http.StatusForbidden, "access forbidden")
+			return
+		}
+		post := db.PostByID(id)
+		if post == nil || !a.Moderates(post.Board) {
+			writeAPIError(w, http.StatusNotFound, "unknown post")
+			return
+		}
+		var block []int
+		for _, v := range r.Form["block"] {
+			if reportID := parseInt(v); reportID > 0 {
+				block = append(block, reportID)
+			}
+		}
+		if len(block) != 0 && !a.Can(PermissionBan) {
+			writeAPIError(w, http.StatusForbidden, "access forbidden")
+			return
+		}
+		s.dismissReports(db, a, post, block, formInt64(r, "blockexpire"))
+		writeJSON(w, http.StatusOK, newAPIPost(post))
+	case len(split) == 3 && split[0] == "mod":
+		s.serveAPIMod(db, a, w, r, split[1], id)
+	default:
+		writeAPIError(w, http.StatusNotFound, "unknown endpoint")
+	}
+}
+
+// serveAPIMod performs a mod action on a post. The post is returned unless it
+// was deleted.
+func (s *Server) serveAPIMod(db *Database, a *Account, w http.ResponseWriter, r *http.Request, action string, postID int) {
+	var required []Permission
+	switch action {
+	case "sticky", "unsticky", "lock", "unlock":
+		required = []Permission{PermissionThread}
+	case "ban", "removebanmessage":
+		required = []Permission{PermissionBan}
+	case "delete":
+		required = []Permission{PermissionDelete}
+	case "deleteban":
+		required = []Permission{PermissionDelete, PermissionBan}
+	default:
+		writeAPIError(w, http.StatusNotFound, "unknown mod action")
+		return
+	}
+	for _, p := range required {
+		if !a.Can(p) {
+			writeAPIError(w, http.StatusForbidden, "access forbidden")
+			return
+		}
+	}
+
+	post := db.PostByID(postID)
+	if post == nil || !a.Moderates(post.Board) {
+		writeAPIError(w, http.StatusNotFound, "unknown post")
+		return
+	}
+
+	switch action {
+	case "sticky", "unsticky", "lock", "unlock":
+		if post.Parent != 0 {
+			writeAPIError(w, http.StatusBadRequest, "post is not a thread")
+			return
+		}
+		statusActions := map[string]string{"sticky": "s", "unsticky": "us", "lock": "l", "unlock": "ul"}
+		s.updateThreadStatus(db, a, post, statusActions[action])
+	case "removebanmessage":
+		s.removeBanMessage(db, a, post)
+	case "ban", "deleteban":
+		err := s.banPoster(db, a, post, banSubject(post, formString(r, "subject")), r)
+		if err == errForbidden {
+			writeAPIError(w, http.StatusForbidden, err.Error())
+			return
+		} else if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if action == "ban" && formBool(r, "banmessage") {
+			s.addBanMessage(db, a, post, formString(r, "banmessagetext"))
+		}
+	}
+	if action == "delete" || action == "deleteban" {
+		s.modDeletePost(db, a, post)
+		writeJSON(w, http.StatusOK, map[string]int{"deleted": post.ID})
+		return
+	}
+	writeJSON(w, http.StatusOK, newAPIPost(db.PostByID(post.ID)))
+}
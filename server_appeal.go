@@ -0,0 +1,115 @@
+package sriracha
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/leonelquinteros/gotext"
+)
+
+func (s *Server) serveAppeal(db *Database, w http.ResponseWriter, r *http.Request) {
+	data := s.buildData(db, w, r)
+
+	var ban *Ban
+	if r.Method == http.MethodPost {
+		banID := formInt(r, "ban")
+		for _, b := range s.requestBans(db, r) {
+			if b.ID == banID && b.Type != BanWarning {
+				ban = b
+				break
+			}
+		}
+	}
+	if ban == nil {
+		data.ManageError(gotext.Get("No ban selected."))
+		data.execute(w)
+		return
+	} else if db.appealByBan(ban) != nil {
+		data.ManageError(gotext.Get("An appeal has already been submitted for this ban."))
+		data.execute(w)
+		return
+	}
+
+	now := time.Now()
+	ip := hashIP(r)
+	if db.recentAppeal(ip, now.Add(-appealDelay).Unix()) {
+		data.ManageError(gotext.Get("Please wait %s before submitting another appeal.", appealDelay))
+		data.execute(w)
+		return
+	}
+
+	appeal := &Appeal{
+		Ban:       ban,
+		IP:        ip,
+		Timestamp: now.Unix(),
+		Message:   formString(r, "message"),
+	}
+	err := appeal.validate()
+	if err != nil {
+		data.ManageError(err.Error())
+		data.execute(w)
+		return
+	}
+	db.addAppeal(appeal)
+
+	s.serveBanned(db, w, r, ban)
+}
+
+func (s *Server) serveAppealDecision(data *templateData, db *Database, w http.ResponseWriter, r *http.Request) {
+	appeal := db.appealByID(formInt(r, "appeal"))
+	if appeal == nil || appeal.Ban == nil || appeal.Status != AppealPending || !data.Account.ModeratesBan(appeal.Ban) {
+		data.ManageError("Invalid appeal.")
+		return
+	}
+
+	decision := formString(r, "decision")
+	if data.forbidden(w, PermissionAppeal) || (decision != "deny" && data.forbidden(w, PermissionLiftBan)) {
+		return
+	}
+
+	ban := appeal.Ban
+	appeal.Response = formString(r, "response")
+
+	var response string
+	if appeal.Response != "" {
+		response = " Response: " + appeal.Response
+	}
+
+	switch decision {
+	case "lift":
+		db.deleteBan(ban.ID)
+		if ban.IsRange() {
+			s.reloadBans(db)
+		}
+
+		db.log(data.Account, nil, fmt.Sprintf("Accepted appeal for >>/ban/%d", ban.ID), "Lifted ban."+response)
+	case "shorten":
+		oldBan := *ban
+		ban.Expire = formInt64(r, "expire")
+		if ban.Expire <= 0 || (oldBan.Expire != 0 && ban.Expire >= oldBan.Expire) {
+			data.ManageError("The new expiration must be earlier than the current expiration.")
+			return
+		}
+		db.updateBan(ban)
+		if ban.IsRange() {
+			s.reloadBans(db)
+		}
+
+		appeal.Status = AppealAccepted
+		db.updateAppeal(appeal)
+
+		changes := printChanges(oldBan, *ban)
+		db.log(data.Account, nil, fmt.Sprintf("Accepted appeal for >>/ban/%d", ban.ID), changes+response)
+	case "deny":
+		appeal.Status = AppealDenied
+		db.updateAppeal(appeal)
+
+		db.log(data.Account, nil, fmt.Sprintf("Denied appeal for >>/ban/%d", ban.ID), response)
+	default:
+		data.ManageError("Unknown decision.")
+		return
+	}
+
+	http.Redirect(w, r, "/sriracha/", http.StatusFound)
+}
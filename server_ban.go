@@ -8,29 +8,26 @@ import (
 )
 
 func (s *Server) serveBan(data *templateData, db *Database, w http.ResponseWriter, r *http.Request) {
+	if !data.Account.Can(PermissionBan) && data.forbidden(w, PermissionLiftBan) {
+		return
+	}
 	data.Template = "manage_ban"
 	data.Boards = db.AllBoards()
+	if data.Account.BoardScoped() {
+		data.Boards = data.Account.Boards
+	}
 
 	deleteBanID := pathInt(r, "/sriracha/ban/delete/")
-	if deleteBanID > 0 {
+	if deleteBanID > 0 && r.Method == http.MethodPost {
+		if data.forbidden(w, PermissionLiftBan) {
+			return
+		}
 		b := db.banByID(deleteBanID)
-		if b == nil {
+		if b == nil || !data.Account.ModeratesBan(b) {
 			data.ManageError("Invalid ban.")
 			return
 		}
-		db.deleteBan(b.ID)
-
-		if strings.HasPrefix(b.IP, "r ") {
-			s.reloadBans(db)
-		}
-
-		var changes string
-		liftReason := formString(r, "reason")
-		if strings.TrimSpace(liftReason) != "" {
-			changes = "Reason: " + liftReason
-		}
-
-		db.log(data.Account, nil, fmt.Sprintf("Lifted >>/ban/%d", b.ID), changes)
+		s.liftBan(db, data.Account, b, formString(r, "reason"))
 
 		http.Redirect(w, r, "/sriracha/ban/", http.StatusFound)
 		return
@@ -39,13 +36,21 @@ func (s *Server) serveBan(data *templateData, db *Database, w http.ResponseWrite
 	banID := pathInt(r, "/sriracha/ban/")
 	if banID > 0 {
 		data.Manage.Ban = db.banByID(banID)
+		if data.Manage.Ban == nil || !data.Account.ModeratesBan(data.Manage.Ban) {
+			data.ManageError("Invalid ban.")
+			return
+		}
 
-		if data.Manage.Ban != nil && r.Method == http.MethodPost {
+		if r.Method == http.MethodPost {
+			if data.forbidden(w, PermissionBan) {
+				return
+			}
 			oldBan := *data.Manage.Ban
-			data.Manage.Ban.loadForm(r)
+			data.Manage.Ban.loadForm(db, r)
+			data.Manage.Ban.Boards = data.Account.scopeBoards(data.Manage.Ban.Boards)
 
 			shorter := data.Manage.Ban.Expire != 0 && (oldBan.Expire == 0 || data.Manage.Ban.Expire < oldBan.Expire)
-			if shorter && data.forbidden(w, RoleAdmin) {
+			if shorter && data.forbidden(w, PermissionLiftBan) {
 				return
 			}
 
@@ -57,7 +62,7 @@ func (s *Server) serveBan(data *templateData, db *Database, w http.ResponseWrite
 
 			db.updateBan(data.Manage.Ban)
 
-			if strings.HasPrefix(data.Manage.Ban.IP, "r ") {
+			if data.Manage.Ban.IsRange() || data.Manage.Ban.IsName() {
 				s.reloadBans(db)
 			}
 
@@ -71,20 +76,49 @@ func (s *Server) serveBan(data *templateData, db *Database, w http.ResponseWrite
 	}
 
 	if r.Method == http.MethodPost {
+		if data.forbidden(w, PermissionBan) {
+			return
+		}
 		b := &Ban{}
-		b.loadForm(r)
+		b.loadForm(db, r)
+		b.Boards = data.Account.scopeBoards(b.Boards)
 
 		ip := formString(r, "ip")
-		if strings.ContainsRune(ip, '*') {
-			pattern := strings.ReplaceAll(strings.ReplaceAll(ip, ".", `\.`), "*", ".*")
-			_, err := regexp.Compile(pattern)
+		switch formString(r, "subject") {
+		case "tripcode":
+			if ip != "" {
+				b.IP = "t " + strings.TrimPrefix(ip, "!")
+			}
+		case "file":
+			if ip != "" {
+				b.IP = "f " + ip
+			}
+		case "name":
+			_, err := regexp.Compile(ip)
 			if err != nil {
-				data.ManageError(fmt.Sprintf("failed to compile ban `%s` as regular expression: %s", pattern, err))
+				data.ManageError(fmt.Sprintf("failed to compile ban `%s` as regular expression: %s", ip, err))
 				return
+			} else if ip != "" {
+				b.IP = "n " + ip
+			}
+		default:
+			if prefix, ok := parseBanRange(ip); ok {
+				b.IP = "c " + prefix.String()
+				b.Range = prefix.String()
+			} else if strings.ContainsRune(ip, '/') {
+				data.ManageError(fmt.Sprintf("failed to parse ban `%s` as an IP address range", ip))
+				return
+			} else if strings.ContainsRune(ip, '*') {
+				pattern := strings.ReplaceAll(strings.ReplaceAll(ip, ".", `\.`), "*", ".*")
+				_, err := regexp.Compile(pattern)
+				if err != nil {
+					data.ManageError(fmt.Sprintf("failed to compile ban `%s` as regular expression: %s", pattern, err))
+					return
+				}
+				b.IP = "r " + pattern
+			} else if ip != "" {
+				b.IP = _hashIP(ip)
 			}
-			b.IP = "r " + pattern
-		} else if ip != "" {
-			b.IP = _hashIP(ip)
 		}
 
 		err := b.validate()
@@ -95,13 +129,13 @@ func (s *Server) serveBan(data *templateData, db *Database, w http.ResponseWrite
 
 		match := db.banByIP(b.IP)
 		if match != nil {
-			data.ManageError("A ban for that IP address or range already exists.")
+			data.ManageError("A ban for that IP address, range, tripcode, file or name already exists.")
 			return
 		}
 
 		db.addBan(b)
 
-		if strings.HasPrefix(b.IP, "r ") {
+		if b.IsRange() || b.IsName() {
 			s.reloadBans(db)
 		}
 
@@ -111,5 +145,24 @@ func (s *Server) serveBan(data *templateData, db *Database, w http.ResponseWrite
 		return
 	}
 
-	data.Manage.Bans = db.allBans(false)
+	for _, b := range db.allBans(false) {
+		if data.Account.ModeratesBan(b) {
+			data.Manage.Bans = append(data.Manage.Bans, b)
+		}
+	}
+}
+
+func (s *Server) liftBan(db *Database, a *Account, b *Ban, reason string) {
+	db.deleteBan(b.ID)
+
+	if b.IsRange() || b.IsName() {
+		s.reloadBans(db)
+	}
+
+	var changes string
+	if strings.TrimSpace(reason) != "" {
+		changes = "Reason: " + reason
+	}
+
+	db.log(a, nil, fmt.Sprintf("Lifted >>/ban/%d", b.ID), changes)
 }
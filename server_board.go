@@ -18,8 +18,8 @@ func (s *Server) serveBoard(data *templateData, db *Database, w http.ResponseWri
 	data.Template = "manage_board"
 
 	boardID := pathInt(r, "/sriracha/board/rebuild/")
-	if boardID > 0 {
-		if data.forbidden(w, RoleAdmin) {
+	if boardID > 0 && r.Method == http.MethodPost {
+		if data.forbidden(w, PermissionBoard) {
 			return false
 		}
 		b := db.BoardByID(boardID)
@@ -58,7 +58,12 @@ func (s *Server) serveBoard(data *templateData, db *Database, w http.ResponseWri
 		data.Boards = db.AllBoards()
 		data.ModMode = true
 		if postID > 0 {
-			data.Threads = [][]*Post{db.AllPostsInThread(postID, true)}
+			posts := db.AllPostsInThread(postID, true)
+			if b.Type == TypeForum && len(posts) > 0 {
+				posts[0].Poll = db.pollByPost(posts[0].ID)
+			}
+			paginateThread(b, posts)
+			data.Threads = [][]*Post{posts}
 			data.ReplyMode = postID
 		} else {
 			allThreads := db.AllThreads(b, true)
@@ -85,8 +90,8 @@ func (s *Server) serveBoard(data *templateData, db *Database, w http.ResponseWri
 	}
 
 	deleteBoardID := pathInt(r, "/sriracha/board/delete/")
-	if deleteBoardID > 0 {
-		if data.forbidden(w, RoleSuperAdmin) {
+	if deleteBoardID > 0 && r.Method == http.MethodPost {
+		if data.forbidden(w, PermissionDeleteBoard) {
 			return
 		}
 
@@ -100,6 +105,7 @@ func (s *Server) serveBoard(data *templateData, db *Database, w http.ResponseWri
 		if !formBool(r, "confirmation") {
 			data.Template = "manage_info"
 			data.Message = template.HTML(`<form method="post">
+			<input type="hidden" name="csrf" value="` + data.CSRF() + `">
 			<input type="hidden" name="confirmation" value="1">
 			<fieldset>
 				<legend>
@@ -153,7 +159,7 @@ func (s *Server) serveBoard(data *templateData, db *Database, w http.ResponseWri
 		}
 
 		if data.Manage.Board != nil && r.Method == http.MethodPost {
-			if data.forbidden(w, RoleAdmin) {
+			if data.forbidden(w, PermissionBoard) {
 				return false
 			}
 			oldBoard := *data.Manage.Board
@@ -233,7 +239,7 @@ func (s *Server) serveBoard(data *templateData, db *Database, w http.ResponseWri
 				for _, info := range db.AllThreads(data.Manage.Board, false) {
 					for _, post := range db.AllPostsInThread(info[0], false) {
 						var modified bool
-						resPattern, err := regexp.Compile(`<a href="` + regexp.QuoteMeta(oldPath) + `res\/([0-9]+).html#([0-9]+)"`)
+						resPattern, err := regexp.Compile(`<a href="` + regexp.QuoteMeta(oldPath) + `res\/([0-9]+(?:-[0-9]+)?).html#([0-9]+)"`)
 						if err != nil {
 							log.Fatalf("failed to compile res pattern: %s", err)
 						}
@@ -261,7 +267,7 @@ func (s *Server) serveBoard(data *templateData, db *Database, w http.ResponseWri
 	}
 
 	if r.Method == http.MethodPost {
-		if data.forbidden(w, RoleAdmin) {
+		if data.forbidden(w, PermissionBoard) {
 			return
 		}
 		b := &Board{}
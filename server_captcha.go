@@ -1,7 +1,11 @@
 package sriracha
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"html"
+	"html/template"
 	"image/color"
 	"log"
 	"net/http"
@@ -10,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/leonelquinteros/gotext"
 	"github.com/steambap/captcha"
 )
 
@@ -24,10 +29,15 @@ func (s *Server) serveCAPTCHA(db *Database, w http.ResponseWriter, r *http.Reque
 		refreshLimit  = 3
 	)
 
+	if r.URL.Path == "/sriracha/captcha/pow" {
+		s.serveProofOfWork(db, w, r)
+		return
+	}
+
 	ipHash := hashIP(r)
 
 	c := db.getCAPTCHA(ipHash)
-	if c != nil {
+	if c != nil && c.Text != "" {
 		queryValues := r.URL.Query()
 		if len(queryValues["new"]) == 0 || c.Refresh >= refreshLimit {
 			http.Redirect(w, r, fmt.Sprintf("/captcha/%s.png", c.Image), http.StatusFound)
@@ -80,3 +90,171 @@ func (s *Server) serveCAPTCHA(db *Database, w http.ResponseWriter, r *http.Reque
 
 	http.Redirect(w, r, fmt.Sprintf("/captcha/%s.png", c.Image), http.StatusFound)
 }
+
+// serveProofOfWork serves the proof of work challenge issued to the client.
+// Challenges are stored in the image column of the captcha table and have no
+// text.
+func (s *Server) serveProofOfWork(db *Database, w http.ResponseWriter, r *http.Request) {
+	ipHash := hashIP(r)
+
+	c := db.getCAPTCHA(ipHash)
+	if c == nil {
+		c = &CAPTCHA{
+			IP:        ipHash,
+			Timestamp: time.Now().Unix(),
+			Image:     db.newCAPTCHAImage(),
+		}
+		db.addCAPTCHA(c)
+	} else if c.Text != "" {
+		os.Remove(filepath.Join(s.config.Root, "captcha", c.Image+".png"))
+
+		c.Image = db.newCAPTCHAImage()
+		c.Text = ""
+		db.updateCAPTCHA(c)
+	}
+
+	buf, err := json.Marshal(map[string]interface{}{
+		"challenge":  c.Image,
+		"difficulty": s.opt.CAPTCHADifficulty,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(buf)
+}
+
+// proofOfWorkSolved returns whether the SHA-256 hash of a challenge followed
+// by its solution begins with the specified number of zero bits.
+func proofOfWorkSolved(challenge string, solution string, difficulty int) bool {
+	if solution == "" || len(solution) > 20 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(challenge + solution))
+	for i := 0; i < difficulty; i++ {
+		if sum[i/8]&(0x80>>(i%8)) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// imageCAPTCHA is the default CAPTCHA provider. Visitors enter the text shown
+// in an image.
+type imageCAPTCHA struct{}
+
+func (c *imageCAPTCHA) About() string {
+	return "Visitors enter the text shown in an image."
+}
+
+func (c *imageCAPTCHA) CAPTCHA(board *Board) string {
+	return srirachaServer.captchaHTML("captcha_image.gohtml")
+}
+
+func (c *imageCAPTCHA) Solve(db *Database, r *http.Request) bool {
+	solution := strings.ToLower(formString(r, "captcha"))
+	return srirachaServer.solveChallenge(db, r, func(challenge *CAPTCHA) bool {
+		return challenge.Text != "" && solution == challenge.Text
+	})
+}
+
+// proofOfWorkCAPTCHA is solved automatically by the visitor's browser.
+type proofOfWorkCAPTCHA struct{}
+
+func (c *proofOfWorkCAPTCHA) About() string {
+	return "Solved automatically by the visitor's browser."
+}
+
+func (c *proofOfWorkCAPTCHA) CAPTCHA(board *Board) string {
+	return srirachaServer.captchaHTML("captcha_proofofwork.gohtml")
+}
+
+func (c *proofOfWorkCAPTCHA) Solve(db *Database, r *http.Request) bool {
+	solution := formString(r, "captcha")
+	difficulty := srirachaServer.opt.CAPTCHADifficulty
+	return srirachaServer.solveChallenge(db, r, func(challenge *CAPTCHA) bool {
+		return challenge.Text == "" && proofOfWorkSolved(challenge.Image, solution, difficulty)
+	})
+}
+
+func (s *Server) captchaHTML(name string) string {
+	buf := &strings.Builder{}
+	err := s.tpl.ExecuteTemplate(buf, name, nil)
+	if err != nil {
+		log.Fatalf("failed to execute %s: %s", name, err)
+	}
+	return buf.String()
+}
+
+// solveChallenge returns whether the built-in CAPTCHA challenge issued to the
+// client was solved. Solved and expired challenges are removed.
+func (s *Server) solveChallenge(db *Database, r *http.Request, solved func(challenge *CAPTCHA) bool) bool {
+	expired := db.expiredCAPTCHAs()
+	for _, c := range expired {
+		db.deleteCAPTCHA(c.IP)
+		os.Remove(filepath.Join(s.config.Root, "captcha", c.Image+".png"))
+	}
+
+	ipHash := hashIP(r)
+	challenge := db.getCAPTCHA(ipHash)
+	if challenge == nil || !solved(challenge) {
+		return false
+	}
+	db.deleteCAPTCHA(ipHash)
+	os.Remove(filepath.Join(s.config.Root, "captcha", challenge.Image+".png"))
+	return true
+}
+
+// captchaProviderByName returns the CAPTCHA provider with the specified name.
+func captchaProviderByName(name string) *captchaProviderInfo {
+	for _, info := range allCAPTCHAProviders {
+		if info.Name == name {
+			return info
+		}
+	}
+	return nil
+}
+
+// boardCAPTCHA returns the CAPTCHA provider of a board. The image CAPTCHA is
+// used when the provider is not loaded.
+func boardCAPTCHA(b *Board) *captchaProviderInfo {
+	info := captchaProviderByName(b.CAPTCHAProvider)
+	if info == nil {
+		return allCAPTCHAProviders[0]
+	}
+	return info
+}
+
+// solveCAPTCHA returns whether the CAPTCHA challenge of the board's provider
+// was solved.
+func (s *Server) solveCAPTCHA(db *Database, r *http.Request, b *Board) bool {
+	info := boardCAPTCHA(b)
+	if !info.plugin {
+		return info.Provider.Solve(db, r)
+	}
+	pluginDB := &Database{
+		conn:   db.conn,
+		plugin: info.Name,
+	}
+	return info.Provider.Solve(pluginDB, r)
+}
+
+// serveCAPTCHARequired asks the poster to solve a CAPTCHA before the post is
+// submitted again. Uploaded files must be selected again.
+func (s *Server) serveCAPTCHARequired(db *Database, w http.ResponseWriter, r *http.Request, post *Post) {
+	data := s.buildData(db, w, r)
+	data.Template = "manage_captcha"
+	data.Board = post.Board
+	data.Info = gotext.Get("Please solve the CAPTCHA to submit your post.")
+	for key, values := range r.Form {
+		if len(values) == 0 || key == "captcha" {
+			continue
+		}
+		data.Message += template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`+"\n", html.EscapeString(key), html.EscapeString(values[0])))
+	}
+	if post.File != "" && !post.IsEmbed() {
+		data.Extra = "file"
+	}
+	data.execute(w)
+}
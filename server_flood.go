@@ -0,0 +1,294 @@
+package sriracha
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type FloodAction int
+
+// Flood responses.
+const (
+	FloodCAPTCHA  FloodAction = 0
+	FloodApproval FloodAction = 1
+	FloodLock     FloodAction = 2
+)
+
+func formatFloodAction(a FloodAction) string {
+	switch a {
+	case FloodCAPTCHA:
+		return "Require CAPTCHA"
+	case FloodApproval:
+		return "Require approval"
+	case FloodLock:
+		return "No visitor threads"
+	default:
+		return "Unknown"
+	}
+}
+
+// FloodAlert notifies staff of a detected flood.
+type FloodAlert struct {
+	Board     *Board
+	Timestamp int64
+	Message   string
+}
+
+func (a *FloodAlert) TimestampDate() string {
+	return time.Unix(a.Timestamp, 0).Format("2006-01-02 15:04:05 MST")
+}
+
+// maxFloodAlerts is the maximum number of alerts kept until dismissed. Older
+// alerts are discarded.
+const maxFloodAlerts = 100
+
+type floodEntry struct {
+	ip        string
+	timestamp int64
+}
+
+// floodRestore records the settings of a board before they were changed in
+// response to a flood.
+type floodRestore struct {
+	approval BoardApproval
+	lock     BoardLock
+	until    int64
+}
+
+// floodDetector tracks new posts across all boards to detect floods.
+type floodDetector struct {
+	posts      map[int][]int64
+	threads    map[int][]int64
+	duplicates map[string][]floodEntry
+	captcha    map[int]int64
+	restore    map[int]*floodRestore
+	alerts     []*FloodAlert
+	sync.Mutex
+}
+
+// pruneTimestamps removes timestamps which are older than the specified time.
+func pruneTimestamps(timestamps []int64, since int64) []int64 {
+	var i int
+	for i < len(timestamps) && timestamps[i] < since {
+		i++
+	}
+	return timestamps[i:]
+}
+
+// record tracks a new post and returns a description of each flood threshold
+// which was crossed.
+func (f *floodDetector) record(post *Post, opt *ServerOptions) []string {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.posts == nil {
+		f.posts = make(map[int][]int64)
+		f.threads = make(map[int][]int64)
+		f.duplicates = make(map[string][]floodEntry)
+	}
+
+	now := post.Timestamp
+	since := now - int64(opt.FloodWindow)
+	boardID := post.Board.ID
+
+	var reasons []string
+	if opt.FloodPosts > 0 {
+		posts := append(pruneTimestamps(f.posts[boardID], since), now)
+		if len(posts) > opt.FloodPosts {
+			reasons = append(reasons, fmt.Sprintf("%d posts in %d seconds", len(posts), opt.FloodWindow))
+			posts = nil
+		}
+		f.posts[boardID] = posts
+	}
+	if opt.FloodThreads > 0 && post.Parent == 0 {
+		threads := append(pruneTimestamps(f.threads[boardID], since), now)
+		if len(threads) > opt.FloodThreads {
+			reasons = append(reasons, fmt.Sprintf("%d threads in %d seconds", len(threads), opt.FloodWindow))
+			threads = nil
+		}
+		f.threads[boardID] = threads
+	}
+	if opt.FloodDuplicates > 0 {
+		var keys []string
+		message := strings.ToLower(strings.TrimSpace(post.Message))
+		if message != "" {
+			keys = append(keys, "m "+message)
+		}
+		if post.FileHash != "" {
+			keys = append(keys, "f "+post.FileHash)
+		}
+		for _, key := range keys {
+			var entries []floodEntry
+			ips := make(map[string]bool)
+			for _, entry := range f.duplicates[key] {
+				if entry.timestamp >= since {
+					entries = append(entries, entry)
+					ips[entry.ip] = true
+				}
+			}
+			entries = append(entries, floodEntry{ip: post.IP, timestamp: now})
+			ips[post.IP] = true
+
+			if len(ips) > opt.FloodDuplicates {
+				label := "message"
+				if key[0] == 'f' {
+					label = "file"
+				}
+				reasons = append(reasons, fmt.Sprintf("Identical %s posted from %d IP addresses in %d seconds", label, len(ips), opt.FloodWindow))
+				entries = nil
+			}
+			f.duplicates[key] = entries
+		}
+		for key, entries := range f.duplicates {
+			if len(entries) == 0 || entries[len(entries)-1].timestamp < since {
+				delete(f.duplicates, key)
+			}
+		}
+	}
+	return reasons
+}
+
+// requireCAPTCHA returns whether posting in a board temporarily requires a CAPTCHA.
+func (f *floodDetector) requireCAPTCHA(boardID int) bool {
+	f.Lock()
+	defer f.Unlock()
+	return f.captcha[boardID] > time.Now().Unix()
+}
+
+func (f *floodDetector) setCAPTCHA(boardID int, until int64) {
+	f.Lock()
+	defer f.Unlock()
+	if f.captcha == nil {
+		f.captcha = make(map[int]int64)
+	}
+	f.captcha[boardID] = until
+}
+
+// setRestore records the settings of a board which are restored at the
+// specified time. Settings recorded by an earlier flood are kept.
+func (f *floodDetector) setRestore(b *Board, until int64) {
+	f.Lock()
+	defer f.Unlock()
+	if f.restore == nil {
+		f.restore = make(map[int]*floodRestore)
+	}
+	if r := f.restore[b.ID]; r != nil {
+		r.until = until
+		return
+	}
+	f.restore[b.ID] = &floodRestore{
+		approval: b.Approval,
+		lock:     b.Lock,
+		until:    until,
+	}
+}
+
+// expiredRestores removes and returns the recorded settings of each board
+// which should be restored.
+func (f *floodDetector) expiredRestores() map[int]*floodRestore {
+	f.Lock()
+	defer f.Unlock()
+	now := time.Now().Unix()
+	var expired map[int]*floodRestore
+	for boardID, r := range f.restore {
+		if r.until > now {
+			continue
+		} else if expired == nil {
+			expired = make(map[int]*floodRestore)
+		}
+		expired[boardID] = r
+		delete(f.restore, boardID)
+	}
+	return expired
+}
+
+func (f *floodDetector) addAlert(alert *FloodAlert) {
+	f.Lock()
+	defer f.Unlock()
+	if len(f.alerts) >= maxFloodAlerts {
+		f.alerts = f.alerts[len(f.alerts)-maxFloodAlerts+1:]
+	}
+	f.alerts = append(f.alerts, alert)
+}
+
+func (f *floodDetector) allAlerts() []*FloodAlert {
+	f.Lock()
+	defer f.Unlock()
+	alerts := make([]*FloodAlert, len(f.alerts))
+	copy(alerts, f.alerts)
+	return alerts
+}
+
+func (f *floodDetector) dismissAlerts() {
+	f.Lock()
+	defer f.Unlock()
+	f.alerts = nil
+}
+
+// checkFlood records a new post and responds to any detected flood.
+func (s *Server) checkFlood(db *Database, post *Post) {
+	reasons := s.flood.record(post, &s.opt)
+	if len(reasons) == 0 {
+		return
+	}
+	b := post.Board
+
+	until := time.Now().Unix() + int64(s.opt.FloodDuration)
+	var response string
+	switch s.opt.FloodAction {
+	case FloodCAPTCHA:
+		s.flood.setCAPTCHA(b.ID, until)
+		response = fmt.Sprintf("Requiring CAPTCHA for %d seconds.", s.opt.FloodDuration)
+	case FloodApproval:
+		s.flood.setRestore(b, until)
+		if b.Approval != ApprovalAll {
+			b.Approval = ApprovalAll
+			db.updateBoard(b)
+		}
+		response = fmt.Sprintf("Requiring approval of all posts for %d seconds.", s.opt.FloodDuration)
+	case FloodLock:
+		s.flood.setRestore(b, until)
+		if b.Lock == LockNone {
+			b.Lock = LockThread
+			db.updateBoard(b)
+		}
+		response = fmt.Sprintf("Locked board to visitor threads for %d seconds.", s.opt.FloodDuration)
+	}
+
+	message := strings.Join(reasons, ". ") + ". " + response
+	db.log(nil, b, "Detected flood", message)
+
+	s.flood.addAlert(&FloodAlert{
+		Board:     b,
+		Timestamp: time.Now().Unix(),
+		Message:   message,
+	})
+}
+
+// restoreFloodBoards restores the settings of boards which were changed in
+// response to a flood once the flood duration has passed. Settings which were
+// changed by staff in the meantime are kept.
+func (s *Server) restoreFloodBoards(db *Database) {
+	for boardID, r := range s.flood.expiredRestores() {
+		b := db.BoardByID(boardID)
+		if b == nil {
+			continue
+		}
+		var changed bool
+		if b.Approval == ApprovalAll && r.approval != ApprovalAll {
+			b.Approval = r.approval
+			changed = true
+		}
+		if b.Lock == LockThread && r.lock == LockNone {
+			b.Lock = r.lock
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		db.updateBoard(b)
+		db.log(nil, b, "Restored board settings after flood", "")
+	}
+}
@@ -23,7 +23,7 @@ func (s *Server) serveImport(data *templateData, db *Database, w http.ResponseWr
 	data.Message = `<h2 class="managetitle">Import</h2><b>Warning:</b> Backup all files and databases before importing a board.<br><br>`
 
 	const completeMessage = "<b>Import complete.</b><br>Please remove the import option from config.yml and restart Sriracha.<br>"
-	if data.forbidden(w, RoleSuperAdmin) {
+	if data.forbidden(w, PermissionImport) {
 		return
 	} else if !s.config.importMode {
 		data.ManageError("Sriracha is not running in import mode.")
@@ -46,6 +46,9 @@ func (s *Server) serveImport(data *templateData, db *Database, w http.ResponseWr
 			if err != nil {
 				data.Message += template.HTML("<b>Error:</b> Failed to commit changes: " + html.EscapeString(err.Error()))
 			} else {
+				if command == "COMMIT" {
+					s.reloadKeywords(db)
+				}
 				data.Message += template.HTML("<b>Changes committed.</b><br><br>" + completeMessage)
 			}
 		}
@@ -113,7 +116,7 @@ func (s *Server) serveImport(data *templateData, db *Database, w http.ResponseWr
 
 	doImport := formBool(r, "import")
 	if !doImport {
-		data.Message += template.HTML(`<form method="post"><input type="hidden" name="import" value="1">
+		data.Message += template.HTML(`<form method="post"><input type="hidden" name="import" value="1"><input type="hidden" name="csrf" value="` + data.CSRF() + `">
         <table border="0" class="manageform">
             <tr>
                 <td class="postblock"><label for="dir">Board Directory</label></td>
@@ -406,6 +409,7 @@ func (s *Server) serveImport(data *templateData, db *Database, w http.ResponseWr
 			kk := &Keyword{
 				Text:   k.Text,
 				Action: k.Action,
+				Fields: defaultKeywordFields,
 				Boards: []*Board{b},
 			}
 			if strings.HasPrefix(kk.Text, "regexp:") {
@@ -440,6 +444,7 @@ func (s *Server) serveImport(data *templateData, db *Database, w http.ResponseWr
 	if !commit {
 		data.Message += template.HTML("<b>Dry run successful.</b><br>Ready to import.<br><br>")
 		data.Message += template.HTML(`<form method="post">
+		<input type="hidden" name="csrf" value="` + data.CSRF() + `">
 		<input type="hidden" name="import" value="1">
 		<input type="hidden" name="confirmation" value="1">
 		<input type="hidden" name="dir" value="` + html.EscapeString(b.Dir) + `">
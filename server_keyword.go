@@ -3,6 +3,7 @@ package sriracha
 import (
 	"fmt"
 	"html/template"
+	"log"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -10,7 +11,7 @@ import (
 )
 
 func (s *Server) serveKeyword(data *templateData, db *Database, w http.ResponseWriter, r *http.Request) {
-	if data.forbidden(w, RoleAdmin) {
+	if data.forbidden(w, PermissionKeyword) {
 		return
 	}
 	var err error
@@ -41,13 +42,14 @@ func (s *Server) serveKeyword(data *templateData, db *Database, w http.ResponseW
 	}
 
 	deleteKeywordID := pathInt(r, "/sriracha/keyword/delete/")
-	if deleteKeywordID > 0 {
+	if deleteKeywordID > 0 && r.Method == http.MethodPost {
 		k := db.keywordByID(deleteKeywordID)
 		if k == nil {
 			data.ManageError("Invalid keyword.")
 			return
 		}
 		db.deleteKeyword(k.ID)
+		s.reloadKeywords(db)
 
 		db.log(data.Account, nil, fmt.Sprintf("Deleted >>/keyword/%d", k.ID), "")
 
@@ -79,6 +81,7 @@ func (s *Server) serveKeyword(data *templateData, db *Database, w http.ResponseW
 			}
 
 			db.updateKeyword(data.Manage.Keyword)
+			s.reloadKeywords(db)
 
 			changes := printChanges(oldKeyword, *data.Manage.Keyword)
 			db.log(data.Account, nil, fmt.Sprintf("Updated >>/keyword/%d", data.Manage.Keyword.ID), changes)
@@ -106,6 +109,7 @@ func (s *Server) serveKeyword(data *templateData, db *Database, w http.ResponseW
 		}
 
 		db.addKeyword(k)
+		s.reloadKeywords(db)
 
 		db.log(data.Account, nil, fmt.Sprintf("Added >>/keyword/%d", k.ID), "")
 
@@ -115,3 +119,85 @@ func (s *Server) serveKeyword(data *templateData, db *Database, w http.ResponseW
 
 	data.Manage.Keywords = db.allKeywords()
 }
+
+// keywordPattern is a keyword and its compiled regular expression.
+type keywordPattern struct {
+	keyword *Keyword
+	pattern *regexp.Regexp
+	literal bool
+}
+
+// keywordFilter holds the compiled regular expressions of all keywords.
+type keywordFilter struct {
+	patterns []*keywordPattern
+
+	// literals matches when any literal keyword matches. It is used to skip
+	// checking each literal keyword individually when a post matches none of
+	// them. Keywords which use regular expression syntax are always checked
+	// individually, as combining them is slower than checking each of them.
+	literals *regexp.Regexp
+}
+
+// allKeywordFields is every post field keywords may be matched against.
+var allKeywordFields = []string{"name", "email", "subject", "message", "file", "embed"}
+
+// match returns the keywords which may match a post in the specified board.
+// Each returned pattern must still be matched against the post.
+func (f *keywordFilter) match(board *Board, post *Post) []*keywordPattern {
+	if f == nil || len(f.patterns) == 0 {
+		return nil
+	}
+	literal := f.literals == nil
+	if !literal {
+		for _, value := range keywordFieldValues(post, allKeywordFields) {
+			if f.literals.MatchString(value) {
+				literal = true
+				break
+			}
+		}
+	}
+	var patterns []*keywordPattern
+	for _, p := range f.patterns {
+		if (literal || !p.literal) && p.keyword.HasBoard(board.ID) {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// reloadKeywords compiles all keywords.
+func (s *Server) reloadKeywords(db *Database) {
+	s.keywords = newKeywordFilter(db.allKeywords())
+}
+
+// newKeywordFilter compiles the specified keywords. Invalid keywords are logged
+// and skipped.
+func newKeywordFilter(keywords []*Keyword) *keywordFilter {
+	f := &keywordFilter{}
+	var literals []string
+	for _, k := range keywords {
+		pattern, err := regexp.Compile(k.Text)
+		if err != nil {
+			log.Printf("warning: failed to compile keyword `%s` as regular expression: %s", k.Text, err)
+			continue
+		}
+		literal := regexp.QuoteMeta(k.Text) == k.Text
+		f.patterns = append(f.patterns, &keywordPattern{
+			keyword: k,
+			pattern: pattern,
+			literal: literal,
+		})
+		if literal {
+			literals = append(literals, k.Text)
+		}
+	}
+	if len(literals) > 1 {
+		pattern, err := regexp.Compile(strings.Join(literals, "|"))
+		if err != nil {
+			log.Printf("warning: failed to compile combined keyword regular expression: %s", err)
+		} else {
+			f.literals = pattern
+		}
+	}
+	return f
+}
@@ -0,0 +1,61 @@
+package sriracha
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// benchmarkKeywords returns the specified number of keywords. Every other
+// keyword is a regular expression, while the rest are literal words.
+func benchmarkKeywords(b *Board, count int) []*Keyword {
+	keywords := make([]*Keyword, count)
+	for i := range keywords {
+		text := fmt.Sprintf("spamword%d", i)
+		if i%2 == 1 {
+			text = fmt.Sprintf(`(?i)b[a@]dw[o0]rd%d\b`, i)
+		}
+		keywords[i] = &Keyword{
+			ID:     i + 1,
+			Text:   text,
+			Action: "hide",
+			Boards: []*Board{b},
+		}
+	}
+	return keywords
+}
+
+// benchmarkMessage returns a message of roughly 1 KB which ends with suffix.
+func benchmarkMessage(suffix string) string {
+	const sentence = "The quick brown fox jumps over the lazy dog. "
+	message := strings.Repeat(sentence, 1024/len(sentence))
+	return message + suffix
+}
+
+func BenchmarkKeywordMatch(b *testing.B) {
+	board := &Board{ID: 1}
+	for _, count := range []int{100, 300, 500} {
+		f := newKeywordFilter(benchmarkKeywords(board, count))
+		for _, test := range []struct {
+			name    string
+			message string
+		}{
+			{"NoMatch", benchmarkMessage("Nothing to see here.")},
+			{"LiteralMatch", benchmarkMessage(fmt.Sprintf("spamword%d", count-2))},
+			{"RegexMatch", benchmarkMessage(fmt.Sprintf("B@DW0RD%d", count-1))},
+		} {
+			post := &Post{
+				Name:    "Anonymous",
+				Subject: "Benchmark",
+				Message: test.message,
+			}
+			b.Run(fmt.Sprintf("%d/%s", count, test.name), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					for _, p := range f.match(board, post) {
+						p.keyword.matchPost(p.pattern, post)
+					}
+				}
+			})
+		}
+	}
+}
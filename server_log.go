@@ -1,15 +1,100 @@
 package sriracha
 
 import (
+	"encoding/csv"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const logPageSize = 25
 
+type logExport struct {
+	ID        int    `json:"id"`
+	Timestamp int64  `json:"timestamp"`
+	Date      string `json:"date"`
+	Account   string `json:"account"`
+	Board     string `json:"board"`
+	Plugin    string `json:"plugin"`
+	Message   string `json:"message"`
+	Changes   string `json:"changes"`
+}
+
+func newLogExport(l *Log) *logExport {
+	e := &logExport{
+		ID:        l.ID,
+		Timestamp: l.Timestamp,
+		Date:      time.Unix(l.Timestamp, 0).UTC().Format(time.RFC3339),
+		Plugin:    l.Plugin,
+		Message:   l.Message,
+		Changes:   l.Changes,
+	}
+	if l.Account != nil {
+		e.Account = l.Account.Username
+	}
+	if l.Board != nil {
+		e.Board = l.Board.Dir
+	}
+	return e
+}
+
 func (s *Server) serveLog(data *templateData, db *Database, w http.ResponseWriter, r *http.Request) {
+	if data.forbidden(w, PermissionLog) {
+		return
+	}
+	q := &logQuery{}
+	q.loadForm(r)
+
+	switch formString(r, "format") {
+	case "csv", "json":
+		s.serveLogExport(db, data.Account, q, w, r)
+		data.Template = ""
+		return
+	}
+
 	page := pathInt(r, "/sriracha/log/p")
 	data.Template = "manage_log"
-	data.Manage.Logs = db.logsByPage(page)
+	data.Boards = db.AllBoards()
+	data.Manage.Accounts = db.allAccounts()
+	data.Manage.LogActions = logActions
+	data.Manage.LogQuery = q
+	data.Manage.Logs = db.logsByPage(data.Account, q, page)
 	data.Page = page
-	data.Pages = pageCount(db.logCount(), logPageSize)
+	data.Pages = pageCount(db.logCount(data.Account, q), logPageSize)
+}
+
+// serveLogExport writes all logs matching the query as CSV or JSON.
+func (s *Server) serveLogExport(db *Database, a *Account, q *logQuery, w http.ResponseWriter, r *http.Request) {
+	format := formString(r, "format")
+	logs := db.allLogs(a, q)
+	exports := make([]*logExport, len(logs))
+	for i, l := range logs {
+		exports[i] = newLogExport(l)
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="sriracha-log-%s.%s"`, time.Now().Format("20060102-150405"), format))
+	if format == "json" {
+		writeJSON(w, http.StatusOK, exports)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "timestamp", "date", "account", "board", "plugin", "message", "changes"})
+	for _, e := range exports {
+		out.Write([]string{strconv.Itoa(e.ID), strconv.FormatInt(e.Timestamp, 10), e.Date, csvCell(e.Account), csvCell(e.Board), csvCell(e.Plugin), csvCell(e.Message), csvCell(e.Changes)})
+	}
+	out.Flush()
+}
+
+// csvCell prevents spreadsheet applications from evaluating a value as a
+// formula by prefixing values which begin with a formula character.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
 }
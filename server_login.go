@@ -0,0 +1,87 @@
+package sriracha
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Login throttling parameters. Failed attempts are tracked by username and by
+// IP address. After a number of free attempts, each further attempt must wait
+// twice as long as the last. After repeated failures, logging in is locked
+// until the lockout duration has passed.
+const (
+	loginFreeAttempts     = 3
+	loginLockoutFailures  = 10
+	loginLockoutDuration  = 15 * 60
+	maxLoginUsernameLabel = 64
+)
+
+// loginFailureKeys returns the keys used to track failed login attempts.
+func loginFailureKeys(username string, ip string) []string {
+	return []string{"u " + strings.ToLower(username), "i " + ip}
+}
+
+// loginWait returns the number of seconds which must pass before another login
+// attempt is allowed.
+func loginWait(failures int, lastFailure int64, now int64) int64 {
+	var wait int64
+	switch {
+	case failures >= loginLockoutFailures:
+		wait = loginLockoutDuration
+	case failures >= loginFreeAttempts:
+		wait = 1 << (failures - loginFreeAttempts)
+	default:
+		return 0
+	}
+	return max(lastFailure+wait-now, 0)
+}
+
+// login attempts to log in and start a new session. When the attempt fails,
+// an error message is returned instead.
+func (s *Server) login(db *Database, r *http.Request, username string, password string) (*Account, string) {
+	ip := hashIP(r)
+	keys := loginFailureKeys(username, ip)
+
+	now := time.Now().Unix()
+	var wait int64
+	for _, key := range keys {
+		failures, lastFailure := db.loginFailures(key)
+		wait = max(wait, loginWait(failures, lastFailure, now))
+	}
+	if wait > 0 {
+		return nil, fmt.Sprintf("Too many failed login attempts. Try again in %d seconds.", wait)
+	}
+
+	var account *Account
+	if len(password) != 0 {
+		account = db.loginAccount(username, password, formString(r, "code"), r.UserAgent(), ip)
+	}
+	if account != nil {
+		for _, key := range keys {
+			db.deleteLoginFailures(key)
+		}
+		if username == "admin" && password == "admin" && !account.MustChange {
+			account.MustChange = true
+			db.updateAccountMustChange(account.ID, true)
+		}
+		return account, ""
+	}
+
+	var failures int
+	for _, key := range keys {
+		failures = max(failures, db.addLoginFailure(key))
+	}
+
+	label := username
+	if len(label) > maxLoginUsernameLabel {
+		label = label[:maxLoginUsernameLabel]
+	}
+	message := fmt.Sprintf("Failed login as %s", label)
+	if failures == loginLockoutFailures {
+		message = fmt.Sprintf("Locked login as %s", label)
+	}
+	db.log(nil, nil, message, fmt.Sprintf("%d failed attempts", failures))
+	return nil, "Invalid username, password or two-factor code."
+}
@@ -28,6 +28,10 @@ func (s *Server) serveMod(data *templateData, db *Database, w http.ResponseWrite
 				action = "l"
 			case "unlock":
 				action = "ul"
+			case "thread":
+				action = "t"
+			case "removebanmessage":
+				action = "rbm"
 			default:
 				data.ManageError("Unknown mod action")
 				return
@@ -45,69 +49,109 @@ func (s *Server) serveMod(data *templateData, db *Database, w http.ResponseWrite
 	if data.Post == nil {
 		data.ManageError("Unknown post")
 		return
+	} else if data.forbiddenBoard(w, data.Post.Board) {
+		return
 	}
-	threadAction := action == "s" || action == "us" || action == "l" || action == "ul"
-	if threadAction {
+	switch action {
+	case "t", "s", "us", "l", "ul":
+		if data.forbidden(w, PermissionThread) {
+			return
+		}
+	case "b", "rbm":
+		if data.forbidden(w, PermissionBan) {
+			return
+		}
+	case "d":
+		if data.forbidden(w, PermissionDelete) {
+			return
+		}
+	case "db":
+		if data.forbidden(w, PermissionDelete) || data.forbidden(w, PermissionBan) {
+			return
+		}
+	}
+	if action == "t" {
 		if data.Post.Parent != 0 {
 			data.ManageError("Invalid post")
 			return
 		}
+		data.Board = data.Post.Board
+		data.Threads = [][]*Post{{data.Post}}
+		if r.Method == http.MethodPost {
+			oldPost := *data.Post
+			data.Post.loadThreadForm(r)
+			db.updateThreadOptions(data.Post)
+
+			changes := printChanges(oldPost, *data.Post)
+			if changes != "" {
+				db.log(data.Account, data.Post.Board, fmt.Sprintf("Updated thread options >>/post/%d", data.Post.ID), changes)
+			}
 
-		var skipRebuild bool
-		switch {
-		case action == "s" && !data.Post.Stickied:
-			db.stickyPost(data.Post.ID, true)
-			db.log(data.Account, nil, fmt.Sprintf("Stickied >>/post/%d", data.Post.ID), "")
-		case action == "us" && data.Post.Stickied:
-			db.stickyPost(data.Post.ID, false)
-			db.log(data.Account, nil, fmt.Sprintf("Unstickied >>/post/%d", data.Post.ID), "")
-		case action == "l" && !data.Post.Locked:
-			db.lockPost(data.Post.ID, true)
-			db.log(data.Account, nil, fmt.Sprintf("Locked >>/post/%d", data.Post.ID), "")
-		case action == "ul" && data.Post.Locked:
-			db.lockPost(data.Post.ID, false)
-			db.log(data.Account, nil, fmt.Sprintf("Unlocked >>/post/%d", data.Post.ID), "")
-		default:
-			skipRebuild = true
-		}
-		if !skipRebuild {
+			s.enforceThreadLimits(db, data.Post)
 			s.rebuildThread(db, data.Post)
+
+			data.Template = "manage_info"
+			http.Redirect(w, r, fmt.Sprintf("/sriracha/board/mod/%d/%d", data.Post.Board.ID, data.Post.ID), http.StatusFound)
+			return
+		}
+		data.Extra = action
+		return
+	}
+	threadAction := action == "s" || action == "us" || action == "l" || action == "ul"
+	if (action == "rbm" || threadAction) && r.Method != http.MethodPost {
+		if threadAction && data.Post.Parent != 0 {
+			data.ManageError("Invalid post")
+			return
+		}
+		data.Board = data.Post.Board
+		data.Threads = [][]*Post{{data.Post}}
+		data.Extra = action
+		return
+	}
+	if action == "rbm" {
+		s.removeBanMessage(db, data.Account, data.Post)
+
+		data.Template = "manage_info"
+		http.Redirect(w, r, fmt.Sprintf("/sriracha/board/mod/%d/%d", data.Post.Board.ID, data.Post.ID), http.StatusFound)
+		return
+	}
+	if threadAction {
+		if data.Post.Parent != 0 {
+			data.ManageError("Invalid post")
+			return
 		}
 
+		s.updateThreadStatus(db, data.Account, data.Post, action)
+
 		data.Template = "manage_info"
 		http.Redirect(w, r, fmt.Sprintf("/sriracha/board/mod/%d/%d", data.Post.Board.ID, data.Post.ID), http.StatusFound)
 		return
 	}
 	data.Board = data.Post.Board
+	data.Boards = db.AllBoards()
+	if data.Account.BoardScoped() {
+		data.Boards = data.Account.Boards
+	}
 	data.Threads = [][]*Post{{data.Post}}
-	data.Manage.Ban = db.banByIP(data.Post.IP)
-	if r.FormValue("confirmation") == "1" {
-		var oldBan Ban
-		if data.Manage.Ban != nil {
-			oldBan = *data.Manage.Ban
-		}
+
+	subject := banSubject(data.Post, formString(r, "subject"))
+	data.Manage.Ban = db.banByIP(subject)
+	if r.Method == http.MethodPost && r.FormValue("confirmation") == "1" {
 		if action == "b" || action == "db" {
-			if data.Manage.Ban != nil {
-				data.Manage.Ban.loadForm(r)
-				db.updateBan(data.Manage.Ban)
-
-				changes := printChanges(oldBan, *data.Manage.Ban)
-				db.log(data.Account, nil, fmt.Sprintf("Updated >>/ban/%d", data.Manage.Ban.ID), changes)
-			} else {
-				ban := &Ban{}
-				ban.loadForm(r)
-				ban.IP = data.Post.IP
-				db.addBan(ban)
-
-				db.log(data.Account, nil, fmt.Sprintf("Added >>/ban/%d", ban.ID), ban.Info())
+			err := s.banPoster(db, data.Account, data.Post, subject, r)
+			if err == errForbidden {
+				data.ManageError("Access forbidden.")
+				return
+			} else if err != nil {
+				data.ManageError(err.Error())
+				return
 			}
 		}
+		if action == "b" && formBool(r, "banmessage") {
+			s.addBanMessage(db, data.Account, data.Post, formString(r, "banmessagetext"))
+		}
 		if action == "d" || action == "db" {
-			s.deletePost(db, data.Post)
-
-			db.log(data.Account, data.Board, fmt.Sprintf("Deleted No.%d", data.Post.ID), "")
-
-			s.rebuildThread(db, data.Post)
+			s.modDeletePost(db, data.Account, data.Post)
 		}
 
 		label := "Deleted"
@@ -125,3 +169,120 @@ func (s *Server) serveMod(data *templateData, db *Database, w http.ResponseWrite
 
 	data.Extra = action
 }
+
+// banSubject returns the ban subject of the poster of a post. The subject may
+// be the IP address, tripcode or file of the post.
+func banSubject(post *Post, subject string) string {
+	switch subject {
+	case "tripcode":
+		if post.Tripcode != "" {
+			return "t " + post.Tripcode
+		}
+	case "file":
+		if post.FileHash != "" {
+			return "f " + post.FileHash
+		}
+	}
+	return post.IP
+}
+
+// errForbidden is returned when an account lacks permission to perform an action.
+var errForbidden = fmt.Errorf("access forbidden")
+
+// banPoster bans the poster of a post, or updates the existing ban of the
+// subject. Shortening an existing ban requires permission to lift bans, and
+// moderators assigned to specific boards may only update bans of those boards.
+// errForbidden is returned when the account lacks permission.
+func (s *Server) banPoster(db *Database, a *Account, post *Post, subject string, r *http.Request) error {
+	ban := db.banByIP(subject)
+	if ban != nil {
+		if !a.ModeratesBan(ban) {
+			return errForbidden
+		}
+		oldBan := *ban
+		ban.loadForm(db, r)
+		ban.Boards = a.scopeBoards(ban.Boards)
+
+		shorter := ban.Expire != 0 && (oldBan.Expire == 0 || ban.Expire < oldBan.Expire)
+		if shorter && !a.Can(PermissionLiftBan) {
+			return errForbidden
+		}
+
+		err := ban.validate()
+		if err != nil {
+			return err
+		}
+
+		db.updateBan(ban)
+
+		changes := printChanges(oldBan, *ban)
+		db.log(a, nil, fmt.Sprintf("Updated >>/ban/%d", ban.ID), changes)
+		return nil
+	}
+
+	ban = &Ban{}
+	ban.loadForm(db, r)
+	ban.Boards = a.scopeBoards(ban.Boards)
+	ban.IP = subject
+
+	err := ban.validate()
+	if err != nil {
+		return err
+	}
+
+	db.addBan(ban)
+
+	db.log(a, nil, fmt.Sprintf("Added >>/ban/%d", ban.ID), ban.Info())
+	return nil
+}
+
+func (s *Server) addBanMessage(db *Database, a *Account, post *Post, banMessage string) {
+	banMessage = strings.TrimSpace(banMessage)
+	if banMessage == "" {
+		banMessage = s.opt.BanMessage
+	}
+	db.updatePostBanMessage(post.ID, banMessage)
+	db.log(a, post.Board, fmt.Sprintf("Added ban message to >>/post/%d", post.ID), banMessage)
+
+	s.rebuildThread(db, post)
+}
+
+func (s *Server) removeBanMessage(db *Database, a *Account, post *Post) {
+	if post.BanMessage == "" {
+		return
+	}
+	db.updatePostBanMessage(post.ID, "")
+	db.log(a, post.Board, fmt.Sprintf("Removed ban message from >>/post/%d", post.ID), "")
+
+	s.rebuildThread(db, post)
+}
+
+func (s *Server) modDeletePost(db *Database, a *Account, post *Post) {
+	s.deletePost(db, post)
+
+	db.log(a, post.Board, fmt.Sprintf("Deleted No.%d", post.ID), "")
+
+	s.rebuildThread(db, post)
+}
+
+// updateThreadStatus stickies (s), unstickies (us), locks (l) or unlocks (ul)
+// a thread.
+func (s *Server) updateThreadStatus(db *Database, a *Account, post *Post, action string) {
+	switch {
+	case action == "s" && !post.Stickied:
+		db.stickyPost(post.ID, true)
+		db.log(a, post.Board, fmt.Sprintf("Stickied >>/post/%d", post.ID), "")
+	case action == "us" && post.Stickied:
+		db.stickyPost(post.ID, false)
+		db.log(a, post.Board, fmt.Sprintf("Unstickied >>/post/%d", post.ID), "")
+	case action == "l" && !post.Locked:
+		db.lockPost(post.ID, true)
+		db.log(a, post.Board, fmt.Sprintf("Locked >>/post/%d", post.ID), "")
+	case action == "ul" && post.Locked:
+		db.lockPost(post.ID, false)
+		db.log(a, post.Board, fmt.Sprintf("Unlocked >>/post/%d", post.ID), "")
+	default:
+		return
+	}
+	s.rebuildThread(db, post)
+}
@@ -11,12 +11,15 @@ import (
 )
 
 func (s *Server) serveNews(data *templateData, db *Database, w http.ResponseWriter, r *http.Request) {
+	if !data.Account.Can(PermissionNews) && data.forbidden(w, PermissionAllNews) {
+		return
+	}
 	var err error
 	data.Template = "manage_news"
 	data.Boards = db.AllBoards()
 
 	deleteNewsID := pathInt(r, "/sriracha/news/delete/")
-	if deleteNewsID > 0 {
+	if deleteNewsID > 0 && r.Method == http.MethodPost {
 		news := db.newsByID(deleteNewsID)
 		if news == nil {
 			data.ManageError("Invalid news item.")
@@ -74,6 +77,9 @@ func (s *Server) serveNews(data *templateData, db *Database, w http.ResponseWrit
 	}
 
 	if r.Method == http.MethodPost {
+		if data.forbidden(w, PermissionNews) {
+			return
+		}
 		n := &News{}
 		n.Account = data.Account
 		n.loadForm(db, r, data.Account)
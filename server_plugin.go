@@ -9,14 +9,14 @@ import (
 )
 
 func (s *Server) servePlugin(data *templateData, db *Database, w http.ResponseWriter, r *http.Request) {
-	if data.forbidden(w, RoleAdmin) {
+	if data.forbidden(w, PermissionPlugin) {
 		return
 	}
 	data.Template = "manage_plugin"
 	data.Boards = db.AllBoards()
 
 	plugin, info := pluginByName(pathString(r, "/sriracha/plugin/reset/"))
-	if plugin != nil {
+	if plugin != nil && r.Method == http.MethodPost {
 		var changes string
 		pUpdate, _ := plugin.(PluginWithUpdate)
 		for i, c := range info.Config {
@@ -78,7 +78,11 @@ func (s *Server) servePlugin(data *templateData, db *Database, w http.ResponseWr
 				http.Redirect(w, r, "/sriracha/plugin/", http.StatusFound)
 				return
 			}
-			msg, err := pServe.Serve(db, data.Account, w, r)
+			pluginDB := &Database{
+				conn:   db.conn,
+				plugin: strings.ToLower(info.Name),
+			}
+			msg, err := pServe.Serve(pluginDB, data.Account, w, r)
 			if err != nil {
 				data.ManageError(err.Error())
 				return
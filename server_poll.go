@@ -0,0 +1,70 @@
+package sriracha
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/leonelquinteros/gotext"
+)
+
+func (s *Server) serveVote(db *Database, w http.ResponseWriter, r *http.Request) {
+	data := s.buildData(db, w, r)
+
+	if r.Method != http.MethodPost {
+		data.BoardError(w, gotext.Get("No poll selected."))
+		return
+	}
+
+	poll := db.pollByID(formInt(r, "poll"))
+	if poll == nil {
+		data.BoardError(w, gotext.Get("No poll selected."))
+		return
+	}
+
+	post := db.PostByID(poll.Post)
+	if post == nil || post.Moderated == ModeratedHidden {
+		data.BoardError(w, gotext.Get("No poll selected."))
+		return
+	}
+	data.Board = post.Board
+
+	if poll.Closed() {
+		data.BoardError(w, gotext.Get("That poll is closed."))
+		return
+	} else if post.Locked {
+		data.BoardError(w, gotext.Get("That thread is locked."))
+		return
+	}
+
+	var options []int
+	for _, v := range r.Form["option"] {
+		optionID := parseInt(v)
+		if !poll.HasOption(optionID) {
+			continue
+		}
+		options = append(options, optionID)
+	}
+	if len(options) == 0 {
+		data.BoardError(w, gotext.Get("Please select an option."))
+		return
+	} else if len(options) > 1 && !poll.Multiple {
+		data.BoardError(w, gotext.Get("Please select only one option."))
+		return
+	}
+
+	ip := hashIP(r)
+	if db.pollVoted(poll.ID, ip) {
+		data.BoardError(w, gotext.Get("You have already voted in this poll."))
+		return
+	}
+
+	now := time.Now().Unix()
+	for _, optionID := range options {
+		db.addPollVote(poll.ID, optionID, ip, now)
+	}
+
+	s.writeThread(db, post.Board, post.ID)
+
+	http.Redirect(w, r, fmt.Sprintf("%sres/%d.html#%d", post.Board.Path(), post.ID, post.ID), http.StatusFound)
+}
@@ -8,7 +8,6 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
@@ -46,6 +45,8 @@ func (s *Server) servePost(db *Database, w http.ResponseWriter, r *http.Request)
 		data := s.buildData(db, w, r)
 		data.BoardError(w, gotext.Get("No board specified."))
 		return
+	} else if s.checkPostingBan(db, w, r, b) {
+		return
 	}
 
 	var (
@@ -54,11 +55,11 @@ func (s *Server) servePost(db *Database, w http.ResponseWriter, r *http.Request)
 		staffCapcode string
 	)
 	data := s.buildData(db, w, r)
-	if data.Account != nil {
-		staffPost = formString(r, "capcode") != ""
+	if data.Account != nil && !data.Account.Restricted() && data.validCSRF(r) {
+		staffPost = formString(r, "capcode") != "" && data.Account.Can(PermissionCapcode)
 		if staffPost {
 			capcode := formInt(r, "capcode")
-			if capcode < 0 || capcode > 2 || (data.Account.Role == RoleMod && capcode == 2) {
+			if capcode < 0 || capcode > 2 || (capcode == 2 && !data.Account.Can(PermissionAdminCapcode)) {
 				capcode = 0
 			}
 			switch capcode {
@@ -68,7 +69,7 @@ func (s *Server) servePost(db *Database, w http.ResponseWriter, r *http.Request)
 				staffCapcode = "Admin"
 			}
 
-			rawHTML = formBool(r, "raw")
+			rawHTML = formBool(r, "raw") && data.Account.Can(PermissionRawHTML)
 		}
 	}
 
@@ -95,6 +96,10 @@ func (s *Server) servePost(db *Database, w http.ResponseWriter, r *http.Request)
 
 	post.IP = hashIP(r)
 
+	if b.Flags {
+		post.Country = s.lookupCountry(requestIP(r))
+	}
+
 	if b.Delay != 0 {
 		lastPost := db.lastPostByIP(post.Board, post.IP)
 		if lastPost != nil {
@@ -129,9 +134,25 @@ func (s *Server) servePost(db *Database, w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	var poll *Poll
+	if b.Type == TypeForum && post.Parent == 0 && formString(r, "poll") != "" {
+		poll = &Poll{}
+		poll.loadForm(r)
+		err := poll.validate()
+		if err != nil {
+			s.deletePostFiles(post)
+
+			data := s.buildData(db, w, r)
+			data.BoardError(w, err.Error())
+			return
+		}
+	}
+
 	oekakiPost := b.Oekaki && formBool(r, "oekaki")
 	skipCAPTCHA := oekakiPost && strings.HasSuffix(post.File, ".tgkr")
 
+	var solvedCAPTCHA bool
+
 	if !staffPost {
 		if b.Lock == LockThread && parentPost == nil {
 			s.deletePostFiles(post)
@@ -140,24 +161,9 @@ func (s *Server) servePost(db *Database, w http.ResponseWriter, r *http.Request)
 			data.BoardError(w, gotext.Get("You may only reply to threads."))
 			return
 		}
-		if s.opt.CAPTCHA && !skipCAPTCHA {
-			expired := db.expiredCAPTCHAs()
-			for _, c := range expired {
-				db.deleteCAPTCHA(c.IP)
-				os.Remove(filepath.Join(s.config.Root, "captcha", c.Image+".png"))
-			}
-
-			var solved bool
-			challenge := db.getCAPTCHA(post.IP)
-			if challenge != nil {
-				solution := formString(r, "captcha")
-				if strings.ToLower(solution) == challenge.Text {
-					solved = true
-					db.deleteCAPTCHA(post.IP)
-					os.Remove(filepath.Join(s.config.Root, "captcha", challenge.Image+".png"))
-				}
-			}
-			if !solved {
+		if b.CAPTCHA && !skipCAPTCHA {
+			solvedCAPTCHA = s.solveCAPTCHA(db, r, b)
+			if !solvedCAPTCHA {
 				s.deletePostFiles(post)
 
 				data := s.buildData(db, w, r)
@@ -263,7 +269,7 @@ func (s *Server) servePost(db *Database, w http.ResponseWriter, r *http.Request)
 		if existing != nil {
 			var postLink string
 			if existing.Moderated != ModeratedHidden {
-				postLink = fmt.Sprintf(` <a href="%sres/%d.html#%d">here</a>`, existing.Board.Path(), existing.Thread(), existing.ID)
+				postLink = fmt.Sprintf(` <a href="%s">here</a>`, postURL(db, existing))
 			}
 
 			var uploadType = "file"
@@ -284,87 +290,119 @@ func (s *Server) servePost(db *Database, w http.ResponseWriter, r *http.Request)
 		post.Message = html.UnescapeString(post.Message)
 	}
 
-	var addReport bool
+	var addReport, forceSage bool
 	if !staffPost {
+		if s.checkPostBan(db, w, r, post) {
+			s.deletePostFiles(post)
+			return
+		}
+
 		if parentPost != nil && parentPost.Locked {
 			data := s.buildData(db, w, r)
 			data.BoardError(w, gotext.Get("That thread is locked."))
 			return
 		}
 
-		for _, keyword := range db.allKeywords() {
-			if !keyword.HasBoard(b.ID) {
+		var requireCAPTCHA bool
+		for _, p := range s.keywords.match(b, post) {
+			keyword, rgxp := p.keyword, p.pattern
+			if !keyword.matchPost(rgxp, post) {
 				continue
 			}
-			rgxp, err := regexp.Compile(keyword.Text)
-			if err != nil {
+			db.addKeywordHit(keyword.ID)
+
+			var action string
+			var banExpire int64
+			switch keyword.Action {
+			case "hide":
+				action = "hide"
+			case "report":
+				action = "report"
+			case "delete":
+				action = "delete"
+			case "captcha":
+				action = "captcha"
+			case "sage":
+				action = "sage"
+			case "replace":
+				action = "replace"
+			case "ban1h":
+				action = "ban"
+				banExpire = time.Now().Add(1 * time.Hour).Unix()
+			case "ban1d":
+				action = "ban"
+				banExpire = time.Now().Add(24 * time.Hour).Unix()
+			case "ban2d":
+				action = "ban"
+				banExpire = time.Now().Add(2 * 24 * time.Hour).Unix()
+			case "ban1w":
+				action = "ban"
+				banExpire = time.Now().Add(7 * 24 * time.Hour).Unix()
+			case "ban2w":
+				action = "ban"
+				banExpire = time.Now().Add(14 * 24 * time.Hour).Unix()
+			case "ban1m":
+				action = "ban"
+				banExpire = time.Now().Add(28 * 24 * time.Hour).Unix()
+			case "ban0":
+				action = "ban"
+			case "ban":
+				action = "ban"
+				if keyword.BanExpire != 0 {
+					banExpire = time.Now().Add(time.Duration(keyword.BanExpire) * time.Second).Unix()
+				}
+			default:
 				s.deletePostFiles(post)
-				log.Fatalf("failed to compile regexp %s: %s", keyword.Text, err)
+				log.Fatalf("unknown keyword action: %s", keyword.Action)
 			}
-			if rgxp.MatchString(post.Name) || rgxp.MatchString(post.Email) || rgxp.MatchString(post.Subject) || rgxp.MatchString(post.Message) {
-				var action string
-				var banExpire int64
-				switch keyword.Action {
-				case "hide":
-					action = "hide"
-				case "report":
-					action = "report"
-				case "delete":
-					action = "delete"
-				case "ban1h":
-					action = "ban"
-					banExpire = time.Now().Add(1 * time.Hour).Unix()
-				case "ban1d":
-					action = "ban"
-					banExpire = time.Now().Add(24 * time.Hour).Unix()
-				case "ban2d":
-					action = "ban"
-					banExpire = time.Now().Add(2 * 24 * time.Hour).Unix()
-				case "ban1w":
-					action = "ban"
-					banExpire = time.Now().Add(7 * 24 * time.Hour).Unix()
-				case "ban2w":
-					action = "ban"
-					banExpire = time.Now().Add(14 * 24 * time.Hour).Unix()
-				case "ban1m":
-					action = "ban"
-					banExpire = time.Now().Add(28 * 24 * time.Hour).Unix()
-				case "ban0":
-					action = "ban"
-				default:
-					s.deletePostFiles(post)
-					log.Fatalf("unknown keyword action: %s", keyword.Action)
-				}
 
-				switch action {
-				case "hide":
-					post.Moderated = 0
-				case "report":
-					addReport = true
-				case "ban":
-					existing := db.banByIP(post.IP)
-					if existing == nil {
-						ban := &Ban{
-							IP:        post.IP,
-							Timestamp: time.Now().Unix(),
-							Expire:    banExpire,
-							Reason:    gotext.Get("Detected banned keyword."),
-						}
-						db.addBan(ban)
-
-						db.log(nil, nil, fmt.Sprintf("Added >>/ban/%d", ban.ID), ban.Info()+fmt.Sprintf(" Detected >>/keyword/%d", keyword.ID))
+			switch action {
+			case "hide":
+				post.Moderated = 0
+			case "report":
+				addReport = true
+			case "captcha":
+				requireCAPTCHA = true
+			case "sage":
+				forceSage = true
+			case "replace":
+				keyword.replacePost(rgxp, post)
+			case "ban":
+				existing := db.banByIP(post.IP)
+				if existing == nil {
+					reason := keyword.BanReason
+					if reason == "" {
+						reason = gotext.Get("Detected banned keyword.")
+					}
+					ban := &Ban{
+						IP:        post.IP,
+						Timestamp: time.Now().Unix(),
+						Expire:    banExpire,
+						Reason:    reason,
 					}
+					db.addBan(ban)
+
+					db.log(nil, nil, fmt.Sprintf("Added >>/ban/%d", ban.ID), ban.Info()+fmt.Sprintf(" Detected >>/keyword/%d", keyword.ID))
 				}
+			}
 
-				if action == "delete" || action == "ban" {
-					s.deletePostFiles(post)
+			if action == "delete" || action == "ban" {
+				s.deletePostFiles(post)
 
-					data := s.buildData(db, w, r)
-					data.BoardError(w, gotext.Get("Detected banned keyword."))
-					return
-				}
+				data := s.buildData(db, w, r)
+				data.BoardError(w, gotext.Get("Detected banned keyword."))
+				return
 			}
 		}
+
+		if s.flood.requireCAPTCHA(b.ID) {
+			requireCAPTCHA = true
+		}
+		if requireCAPTCHA && !solvedCAPTCHA && !s.solveCAPTCHA(db, r, b) {
+			s.deletePostFiles(post)
+			s.serveCAPTCHARequired(db, w, r, post)
+			return
+		}
 	}
 
 	if !rawHTML {
@@ -430,7 +468,7 @@ func (s *Server) servePost(db *Database, w http.ResponseWriter, r *http.Request)
 			if refPost.Parent != 0 {
 				className = "refreply"
 			}
-			return fmt.Sprintf(`<a href="%sres/%d.html#%d" class="%s">%s</a>`, refPost.Board.Path(), refPost.Thread(), refPost.ID, className, s)
+			return fmt.Sprintf(`<a href="%s" class="%s">%s</a>`, postLink(refPost), className, s)
 		})
 
 		var quote bool
@@ -507,6 +545,15 @@ func (s *Server) servePost(db *Database, w http.ResponseWriter, r *http.Request)
 
 	db.addPost(post)
 
+	if !staffPost {
+		s.checkFlood(db, post)
+	}
+
+	if poll != nil {
+		poll.Post = post.ID
+		db.addPoll(poll)
+	}
+
 	if post.Moderated == ModeratedHidden {
 		data.Template = "board_info"
 		data.Info = gotext.Get("Your post will be shown once it has been approved.")
@@ -518,6 +565,7 @@ func (s *Server) servePost(db *Database, w http.ResponseWriter, r *http.Request)
 			Post:      post,
 			Timestamp: time.Now().Unix(),
 			IP:        hashIP(r),
+			Category:  "Keyword",
 		}
 		db.addReport(report)
 	}
@@ -526,15 +574,45 @@ func (s *Server) servePost(db *Database, w http.ResponseWriter, r *http.Request)
 		for _, thread := range db.trimThreads(post.Board) {
 			s.deletePost(db, thread)
 		}
-	} else if strings.ToLower(post.Email) != "sage" {
-		bump := post.Board.MaxReplies == 0 || db.replyCount(post.Parent) <= post.Board.MaxReplies
-		if bump {
-			db.bumpThread(post.Parent, now)
+	} else {
+		s.enforceThreadLimits(db, parentPost)
+
+		if strings.ToLower(post.Email) != "sage" && !forceSage && !parentPost.Autosage {
+			bump := post.Board.MaxReplies == 0 || db.replyCount(post.Parent) <= post.Board.MaxReplies
+			if bump {
+				db.bumpThread(post.Parent, now)
+			}
 		}
 	}
 
-	s.rebuildThread(db, post)
+	// Only rewrite the last page of a paginated thread, unless the reply
+	// started a new page or older replies were removed.
+	var firstPage int
+	if post.Parent != 0 && b.paginateThreads() && parentPost.Cycle == 0 {
+		index := db.postIndex(post)
+		if index%b.PostsPerPage != 0 {
+			firstPage = index / b.PostsPerPage
+		}
+	}
+	s.rebuildThreadPages(db, post, firstPage)
 
-	redir := fmt.Sprintf("%sres/%d.html#%d", b.Path(), post.Thread(), post.ID)
-	http.Redirect(w, r, redir, http.StatusFound)
+	// Show any pending warning before redirecting to the post.
+	if ban := db.banByIP(hashIP(r)); ban != nil && ban.Type == BanWarning {
+		http.Redirect(w, r, fmt.Sprintf("/sriracha/post/%d", post.ID), http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, postURL(db, post), http.StatusFound)
+}
+
+// enforceThreadLimits deletes the oldest replies in a cyclical thread and
+// locks a thread once its reply limit has been reached.
+func (s *Server) enforceThreadLimits(db *Database, thread *Post) {
+	for _, reply := range db.trimReplies(thread) {
+		s.deletePost(db, reply)
+	}
+	if thread.ReplyLimit != 0 && !thread.Locked && db.replyCount(thread.ID) >= thread.ReplyLimit {
+		db.lockPost(thread.ID, true)
+		thread.Locked = true
+		db.log(nil, thread.Board, fmt.Sprintf("Locked >>/post/%d", thread.ID), fmt.Sprintf("Reached reply limit of %d", thread.ReplyLimit))
+	}
 }
@@ -1,12 +1,34 @@
 package sriracha
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 )
 
 func (s *Server) servePreference(data *templateData, db *Database, w http.ResponseWriter, r *http.Request) {
 	data.Template = "manage_preference"
+	if data.Account.MustChange {
+		data.Info = "The default password must be changed before continuing."
+	} else if data.Account.Pending2FA() {
+		data.Info = "Two-factor authentication is required for your role. Enable it to continue."
+	}
+	if !data.Account.TwoFactor() {
+		secret := formString(r, "secret")
+		if _, ok := totpCode(secret, 0); secret == "" || !ok {
+			secret = newTOTPSecret()
+		}
+		data.Manage.TOTPSecret = secret
+		data.Manage.TOTPURI = totpURI(s.opt.SiteName, data.Account.Username, secret)
+	}
+	data.Manage.Sessions = db.accountSessions(data.Account.ID)
+	data.Manage.APITokens = db.accountAPITokens(data.Account.ID)
+	for _, p := range apiPermissions {
+		if data.Account.Can(p) {
+			data.Manage.APIPermissions = append(data.Manage.APIPermissions, p)
+		}
+	}
 	if r.Method == http.MethodPost {
 		switch formString(r, "action") {
 		case "style":
@@ -32,7 +54,12 @@ func (s *Server) servePreference(data *templateData, db *Database, w http.Respon
 				return
 			}
 
-			match := db.loginAccount(data.Account.Username, oldPass)
+			if data.Account.MustChange && newPass == oldPass {
+				data.ManageError("New password must differ from the current password")
+				return
+			}
+
+			match := db.accountByPassword(data.Account.Username, oldPass)
 			if match == nil {
 				data.ManageError("Current password is incorrect")
 				return
@@ -42,6 +69,104 @@ func (s *Server) servePreference(data *templateData, db *Database, w http.Respon
 
 			http.Redirect(w, r, "/sriracha/", http.StatusFound)
 			return
+		case "revoke":
+			sessionID := formInt(r, "session")
+			for _, session := range data.Manage.Sessions {
+				if session.ID == sessionID {
+					db.deleteSession(session.ID)
+					break
+				}
+			}
+
+			http.Redirect(w, r, "/sriracha/preference/", http.StatusFound)
+			return
+		case "revokeall":
+			db.deleteAccountSessions(data.Account.ID, data.Account.Session)
+
+			http.Redirect(w, r, "/sriracha/preference/", http.StatusFound)
+			return
+		case "addtoken":
+			if data.Account.Restricted() || len(data.Manage.APIPermissions) == 0 {
+				data.ManageError("Access forbidden.")
+				return
+			}
+			t := &APIToken{AccountID: data.Account.ID}
+			t.loadForm(r, data.Account)
+			err := t.validate()
+			if err != nil {
+				data.ManageError(err.Error())
+				return
+			}
+
+			data.Manage.APIToken = db.addAPIToken(t)
+			data.Manage.APITokens = db.accountAPITokens(data.Account.ID)
+
+			db.log(data.Account, nil, fmt.Sprintf("Added API token %s", t.Name), t.PermissionsLabel())
+		case "revoketoken":
+			tokenID := formInt(r, "token")
+			for _, t := range data.Manage.APITokens {
+				if t.ID == tokenID {
+					db.deleteAPIToken(t.ID)
+					db.log(data.Account, nil, fmt.Sprintf("Revoked API token %s", t.Name), "")
+					break
+				}
+			}
+
+			http.Redirect(w, r, "/sriracha/preference/", http.StatusFound)
+			return
+		case "enable2fa":
+			if data.Account.TwoFactor() {
+				data.ManageError("Two-factor authentication is already enabled")
+				return
+			}
+			step, ok := verifyTOTP(data.Manage.TOTPSecret, formString(r, "code"), time.Now())
+			if !ok {
+				data.Info = "Invalid two-factor code. Ensure the clock of your device is correct and try again."
+				return
+			}
+
+			codes, recovery := newRecoveryCodes()
+			db.updateAccountTOTP(data.Account.ID, data.Manage.TOTPSecret, recovery)
+			db.updateAccountTOTPStep(data.Account.ID, step)
+			data.Account.TOTP = data.Manage.TOTPSecret
+			data.Account.Recovery = recovery
+			data.Manage.RecoveryCodes = codes
+			data.Info = ""
+
+			db.log(data.Account, nil, "Enabled two-factor authentication", "")
+		case "recovery":
+			if !data.Account.TwoFactor() {
+				data.ManageError("Two-factor authentication is not enabled")
+				return
+			} else if !db.verifySecondFactor(data.Account, formString(r, "code")) {
+				data.ManageError("Invalid two-factor code")
+				return
+			}
+
+			codes, recovery := newRecoveryCodes()
+			db.updateAccountRecovery(data.Account.ID, recovery)
+			data.Account.Recovery = recovery
+			data.Manage.RecoveryCodes = codes
+
+			db.log(data.Account, nil, "Regenerated two-factor recovery codes", "")
+		case "disable2fa":
+			if !data.Account.TwoFactor() {
+				data.ManageError("Two-factor authentication is not enabled")
+				return
+			} else if data.Account.Require2FA {
+				data.ManageError("Two-factor authentication is required for your role")
+				return
+			} else if !db.verifySecondFactor(data.Account, formString(r, "code")) {
+				data.ManageError("Invalid two-factor code")
+				return
+			}
+
+			db.updateAccountTOTP(data.Account.ID, "", "")
+
+			db.log(data.Account, nil, "Disabled two-factor authentication", "")
+
+			http.Redirect(w, r, "/sriracha/preference/", http.StatusFound)
+			return
 		}
 	}
 }
@@ -1,12 +1,18 @@
 package sriracha
 
 import (
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/leonelquinteros/gotext"
 )
 
+const maxReportComment = 1000
+
 func (s *Server) serveReport(db *Database, w http.ResponseWriter, r *http.Request) {
 	data := s.buildData(db, w, r)
 
@@ -20,17 +26,86 @@ func (s *Server) serveReport(db *Database, w http.ResponseWriter, r *http.Reques
 	if post == nil {
 		data.BoardError(w, gotext.Get("No post selected."))
 		return
-	} else if post.Moderated == ModeratedVisible {
+	} else if s.checkReportingBan(db, w, r, post.Board) {
+		return
+	}
+
+	if r.Method != http.MethodPost || formString(r, "confirmation") != "1" {
+		data.Board = post.Board
+		data.Post = post
+		data.Template = "board_report"
+		data.execute(w)
+		return
+	}
+
+	category := formString(r, "category")
+	if len(s.opt.ReportCategories) != 0 {
+		var found bool
+		for _, c := range s.opt.ReportCategories {
+			if c == category {
+				found = true
+				break
+			}
+		}
+		if !found {
+			data.BoardError(w, gotext.Get("Please select a reason for reporting."))
+			return
+		}
+	} else {
+		category = ""
+	}
+
+	comment := strings.TrimSpace(formString(r, "comment"))
+	if len(comment) > maxReportComment {
+		data.BoardError(w, gotext.Get("Comment must be %d characters or less.", maxReportComment))
+		return
+	}
+
+	if post.Moderated == ModeratedVisible {
 		report := &Report{
 			Board:     post.Board,
 			Post:      post,
 			Timestamp: time.Now().Unix(),
 			IP:        hashIP(r),
+			Category:  category,
+			Comment:   comment,
 		}
 		db.addReport(report)
+		s.hideReportedPost(db, post)
 	}
 
 	data.Template = "board_info"
 	data.Info = gotext.Get("Reported No.%d", post.ID)
 	data.execute(w)
 }
+
+// hideReportedPost hides a post once it has received as many reports as the
+// report threshold of its board. Hidden posts await approval on the status page.
+// Approved posts are never hidden.
+func (s *Server) hideReportedPost(db *Database, post *Post) {
+	b := post.Board
+	if b == nil || b.ReportThreshold <= 0 || post.Moderated != ModeratedVisible {
+		return
+	}
+	reports := db.postReports(post)
+	if len(reports) < b.ReportThreshold {
+		return
+	}
+
+	db.reportHidePost(post.ID)
+	post.Moderated = ModeratedHidden
+	post.ReportHidden = true
+
+	db.log(nil, b, fmt.Sprintf("Hid >>/post/%d", post.ID), fmt.Sprintf("Reached report threshold of %d", b.ReportThreshold))
+
+	if post.Parent != 0 {
+		s.rebuildThread(db, post)
+		return
+	}
+	os.Remove(filepath.Join(s.config.Root, b.Dir, "res", fmt.Sprintf("%d.html", post.ID)))
+	s.removeThreadPages(b, post.ID, 1)
+	s.writeIndexes(db, b)
+	if s.opt.Overboard != "" {
+		s.writeOverboard(db)
+	}
+}
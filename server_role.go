@@ -0,0 +1,120 @@
+package sriracha
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+func (s *Server) serveRole(data *templateData, db *Database, w http.ResponseWriter, r *http.Request) {
+	if data.Account.Role != RoleSuperAdmin {
+		data.ManageError("Only super-administrators may manage roles.")
+		return
+	}
+	data.Template = "manage_role"
+
+	deleteRoleID := pathInt(r, "/sriracha/role/delete/")
+	if deleteRoleID > 0 && r.Method == http.MethodPost {
+		role := db.roleByID(deleteRoleID)
+		if role == nil {
+			data.ManageError("Invalid role.")
+			return
+		} else if count := db.roleAccountCount(role.ID); count > 0 {
+			data.ManageError(fmt.Sprintf("Role is assigned to %d account(s).", count))
+			return
+		}
+		db.deleteRole(role.ID)
+
+		db.log(data.Account, nil, fmt.Sprintf("Deleted role %s", role.Name), "")
+
+		http.Redirect(w, r, "/sriracha/role/", http.StatusFound)
+		return
+	}
+
+	roleID := pathInt(r, "/sriracha/role/")
+	if roleID > 0 {
+		data.Manage.Role = db.roleByID(roleID)
+
+		if data.Manage.Role != nil && r.Method == http.MethodPost {
+			oldRole := *data.Manage.Role
+			data.Manage.Role.loadForm(r)
+
+			err := data.Manage.Role.validate()
+			if err != nil {
+				data.ManageError(err.Error())
+				return
+			}
+
+			if data.Manage.Role.Name != oldRole.Name {
+				match := db.roleByName(data.Manage.Role.Name)
+				if match != nil {
+					data.ManageError("Role name already taken")
+					return
+				}
+			}
+
+			db.updateRole(data.Manage.Role)
+
+			changes := printChanges(oldRole, *data.Manage.Role)
+			db.log(data.Account, nil, fmt.Sprintf("Updated role %s", data.Manage.Role.Name), changes)
+
+			http.Redirect(w, r, "/sriracha/role/", http.StatusFound)
+			return
+		}
+		return
+	}
+
+	if r.Method == http.MethodPost && formString(r, "action") == "require2fa" {
+		oldOpt := s.opt
+
+		var roles []AccountRole
+		var values []string
+		for _, role := range []AccountRole{RoleSuperAdmin, RoleAdmin, RoleMod} {
+			for _, value := range r.Form["require2fa"] {
+				if value == strconv.Itoa(int(role)) {
+					roles = append(roles, role)
+					values = append(values, value)
+					break
+				}
+			}
+		}
+		db.SaveMultiString("require2fa", values)
+		s.opt.Require2FA = roles
+
+		changes := printChanges(oldOpt, s.opt)
+		if changes != "" {
+			db.log(data.Account, nil, "Updated two-factor authentication requirements", changes)
+		}
+
+		http.Redirect(w, r, "/sriracha/role/", http.StatusFound)
+		return
+	} else if r.Method == http.MethodPost {
+		role := &Role{}
+		role.loadForm(r)
+
+		err := role.validate()
+		if err != nil {
+			data.ManageError(err.Error())
+			return
+		}
+
+		match := db.roleByName(role.Name)
+		if match != nil {
+			data.ManageError("Role name already taken")
+			return
+		}
+
+		db.addRole(role)
+
+		details := role.PermissionsLabel()
+		if role.Require2FA {
+			details += " (two-factor authentication required)"
+		}
+		db.log(data.Account, nil, fmt.Sprintf("Added role %s", role.Name), details)
+
+		http.Redirect(w, r, "/sriracha/role/", http.StatusFound)
+		return
+	}
+
+	data.Manage.Roles = db.allRoles()
+}
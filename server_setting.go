@@ -10,11 +10,11 @@ import (
 )
 
 func (s *Server) serveSetting(data *templateData, db *Database, w http.ResponseWriter, r *http.Request) {
-	if data.forbidden(w, RoleAdmin) {
+	if data.forbidden(w, PermissionSetting) {
 		return
 	}
 
-	if r.URL.Path == "/sriracha/setting/reset" {
+	if r.URL.Path == "/sriracha/setting/reset" && r.Method == http.MethodPost {
 		oldOpt := s.opt
 
 		s.opt.SiteName = defaultServerSiteName
@@ -29,8 +29,8 @@ func (s *Server) serveSetting(data *templateData, db *Database, w http.ResponseW
 		s.opt.BoardIndex = true
 		db.SaveBool("boardindex", s.opt.BoardIndex)
 
-		s.opt.CAPTCHA = false
-		db.SaveBool("captcha", s.opt.CAPTCHA)
+		s.opt.CAPTCHADifficulty = defaultServerCAPTCHADifficulty
+		db.SaveInt("captchadifficulty", s.opt.CAPTCHADifficulty)
 
 		s.opt.OekakiWidth = defaultServerOekakiWidth
 		db.SaveInt("oekakiwidth", s.opt.OekakiWidth)
@@ -53,6 +53,39 @@ func (s *Server) serveSetting(data *templateData, db *Database, w http.ResponseW
 		s.opt.OverboardReplies = defaultBoardReplies
 		db.SaveInt("overboardreplies", s.opt.OverboardReplies)
 
+		s.opt.BanMessage = defaultServerBanMessage
+		db.SaveString("banmessage", s.opt.BanMessage)
+
+		s.opt.BanMessageStyle = defaultServerBanMessageStyle
+		db.SaveString("banmessagestyle", s.opt.BanMessageStyle)
+
+		s.opt.FloodWindow = defaultServerFloodWindow
+		db.SaveInt("floodwindow", s.opt.FloodWindow)
+
+		s.opt.FloodPosts = 0
+		db.SaveInt("floodposts", s.opt.FloodPosts)
+
+		s.opt.FloodThreads = 0
+		db.SaveInt("floodthreads", s.opt.FloodThreads)
+
+		s.opt.FloodDuplicates = 0
+		db.SaveInt("floodduplicates", s.opt.FloodDuplicates)
+
+		s.opt.FloodAction = FloodCAPTCHA
+		db.SaveInt("floodaction", int(s.opt.FloodAction))
+
+		s.opt.FloodDuration = defaultServerFloodDuration
+		db.SaveInt("floodduration", s.opt.FloodDuration)
+
+		s.opt.SessionIdle = defaultServerSessionIdle
+		db.SaveInt("sessionidle", s.opt.SessionIdle)
+
+		s.opt.SessionExpire = defaultServerSessionExpire
+		db.SaveInt("sessionexpire", s.opt.SessionExpire)
+
+		s.opt.ReportCategories = append([]string{}, defaultServerReportCategories...)
+		db.SaveMultiString("reportcategories", s.opt.ReportCategories)
+
 		s.opt.Embeds = nil
 		var embeds []string
 		for _, info := range defaultServerEmbeds {
@@ -108,9 +141,9 @@ func (s *Server) serveSetting(data *templateData, db *Database, w http.ResponseW
 		db.SaveBool("boardindex", boardIndex)
 		s.opt.BoardIndex = boardIndex
 
-		enableCAPTCHA := formBool(r, "captcha")
-		db.SaveBool("captcha", enableCAPTCHA)
-		s.opt.CAPTCHA = enableCAPTCHA
+		captchaDifficulty := formRange(r, "captchadifficulty", 1, maxCAPTCHADifficulty)
+		db.SaveInt("captchadifficulty", captchaDifficulty)
+		s.opt.CAPTCHADifficulty = captchaDifficulty
 
 		oekakiWidth := formInt(r, "oekakiwidth")
 		db.SaveInt("oekakiwidth", oekakiWidth)
@@ -139,6 +172,63 @@ func (s *Server) serveSetting(data *templateData, db *Database, w http.ResponseW
 		db.SaveInt("overboardreplies", overboardReplies)
 		s.opt.OverboardReplies = overboardReplies
 
+		banMessage := formString(r, "banmessage")
+		if banMessage != "" {
+			db.SaveString("banmessage", banMessage)
+			s.opt.BanMessage = banMessage
+		}
+
+		banMessageStyle := formString(r, "banmessagestyle")
+		if banMessageStyle != "" {
+			db.SaveString("banmessagestyle", banMessageStyle)
+			s.opt.BanMessageStyle = banMessageStyle
+		}
+
+		floodWindow := formInt(r, "floodwindow")
+		if floodWindow > 0 {
+			db.SaveInt("floodwindow", floodWindow)
+			s.opt.FloodWindow = floodWindow
+		}
+
+		floodPosts := formInt(r, "floodposts")
+		db.SaveInt("floodposts", floodPosts)
+		s.opt.FloodPosts = floodPosts
+
+		floodThreads := formInt(r, "floodthreads")
+		db.SaveInt("floodthreads", floodThreads)
+		s.opt.FloodThreads = floodThreads
+
+		floodDuplicates := formInt(r, "floodduplicates")
+		db.SaveInt("floodduplicates", floodDuplicates)
+		s.opt.FloodDuplicates = floodDuplicates
+
+		floodAction := formRange(r, "floodaction", FloodCAPTCHA, FloodLock)
+		db.SaveInt("floodaction", int(floodAction))
+		s.opt.FloodAction = floodAction
+
+		floodDuration := formInt(r, "floodduration")
+		if floodDuration > 0 {
+			db.SaveInt("floodduration", floodDuration)
+			s.opt.FloodDuration = floodDuration
+		}
+
+		sessionIdle := formInt(r, "sessionidle")
+		db.SaveInt("sessionidle", sessionIdle)
+		s.opt.SessionIdle = sessionIdle
+
+		sessionExpire := formInt(r, "sessionexpire")
+		db.SaveInt("sessionexpire", sessionExpire)
+		s.opt.SessionExpire = sessionExpire
+
+		s.opt.ReportCategories = nil
+		for _, category := range strings.Split(formString(r, "reportcategories"), "\n") {
+			category = strings.TrimSpace(category)
+			if category != "" && len(category) <= 255 {
+				s.opt.ReportCategories = append(s.opt.ReportCategories, category)
+			}
+		}
+		db.SaveMultiString("reportcategories", s.opt.ReportCategories)
+
 		if overboard != "" && overboard != "/" {
 			os.Mkdir(filepath.Join(s.config.Root, overboard), newDirPermission)
 		}
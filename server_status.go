@@ -2,6 +2,7 @@ package sriracha
 
 import (
 	"bytes"
+	"fmt"
 	"html/template"
 	"net/http"
 	"time"
@@ -9,6 +10,34 @@ import (
 
 func (s *Server) serveStatus(data *templateData, db *Database, w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodPost {
+		if formInt(r, "appeal") > 0 {
+			s.serveAppealDecision(data, db, w, r)
+			return
+		} else if data.forbidden(w, PermissionApprove) {
+			return
+		} else if formBool(r, "dismissflood") {
+			s.flood.dismissAlerts()
+			http.Redirect(w, r, "/sriracha/", http.StatusFound)
+			return
+		}
+
+		dismiss := formInt(r, "dismiss")
+		if dismiss > 0 {
+			post := db.PostByID(dismiss)
+			if post != nil && data.Account.Moderates(post.Board) {
+				var block []int
+				for _, v := range r.Form["block"] {
+					if id := parseInt(v); id > 0 {
+						block = append(block, id)
+					}
+				}
+				if len(block) != 0 && data.forbidden(w, PermissionBan) {
+					return
+				}
+				s.dismissReports(db, data.Account, post, block, formInt64(r, "blockexpire"))
+			}
+		}
+
 		approve := formInt(r, "approve")
 		if approve > 0 {
 			boardID := formInt(r, "board")
@@ -16,16 +45,8 @@ func (s *Server) serveStatus(data *templateData, db *Database, w http.ResponseWr
 				b := db.BoardByID(boardID)
 				if b != nil {
 					post := db.PostByID(approve)
-					if post != nil {
-						rebuild := post.Moderated == ModeratedHidden
-
-						db.moderatePost(post.ID, ModeratedApproved)
-						db.deleteReports(post)
-
-						if rebuild {
-							db.bumpThread(post.Thread(), time.Now().Unix())
-							s.rebuildThread(db, post)
-						}
+					if post != nil && data.Account.Moderates(post.Board) {
+						s.approvePost(db, data.Account, post)
 					}
 				}
 			}
@@ -37,8 +58,21 @@ func (s *Server) serveStatus(data *templateData, db *Database, w http.ResponseWr
 
 	buf := &bytes.Buffer{}
 	data.Template = "manage_status"
+	review := data.Account.Can(PermissionApprove)
 
-	reports := db.allReports()
+	// Reported posts which were hidden automatically are shown with the
+	// pending posts.
+	var reports []*Report
+	hidden := make(map[int]*Report)
+	for _, report := range db.allReports() {
+		if !review || !data.Account.Moderates(report.Post.Board) {
+			continue
+		} else if report.Post.ReportHidden {
+			hidden[report.Post.ID] = report
+			continue
+		}
+		reports = append(reports, report)
+	}
 	for i, report := range reports {
 		if i > 0 {
 			buf.WriteString("<hr>\n")
@@ -55,7 +89,12 @@ func (s *Server) serveStatus(data *templateData, db *Database, w http.ResponseWr
 	data.Message = template.HTML(buf.String())
 
 	buf.Reset()
-	pending := db.pendingPosts()
+	var pending []*Post
+	for _, post := range db.pendingPosts() {
+		if review && data.Account.Moderates(post.Board) {
+			pending = append(pending, post)
+		}
+	}
 	for i, post := range pending {
 		if i > 0 {
 			buf.WriteString("<hr>\n")
@@ -66,7 +105,86 @@ func (s *Server) serveStatus(data *templateData, db *Database, w http.ResponseWr
 		d.Board = post.Board
 		d.Post = post
 		d.Threads = [][]*Post{{post}}
+		d.Manage.Report = hidden[post.ID]
 		d.execute(buf)
 	}
 	data.Message2 = template.HTML(buf.String())
+
+	if data.Account.Can(PermissionAppeal) {
+		for _, appeal := range db.pendingAppeals() {
+			if appeal.Ban != nil && data.Account.ModeratesBan(appeal.Ban) {
+				data.Manage.Appeals = append(data.Manage.Appeals, appeal)
+			}
+		}
+	}
+	for _, alert := range s.flood.allAlerts() {
+		if review && data.Account.Moderates(alert.Board) {
+			data.Manage.FloodAlerts = append(data.Manage.FloodAlerts, alert)
+		}
+	}
+}
+
+// approvePost approves a post and dismisses its reports. Hidden posts are
+// displayed once approved. Threads are only bumped when the post was awaiting
+// approval, not when it was hidden after reaching the report threshold.
+func (s *Server) approvePost(db *Database, a *Account, post *Post) {
+	rebuild := post.Moderated == ModeratedHidden
+	bump := rebuild && !post.ReportHidden
+
+	db.moderatePost(post.ID, ModeratedApproved)
+	db.deleteReports(post)
+
+	db.log(a, post.Board, fmt.Sprintf("Approved >>/post/%d", post.ID), "")
+
+	if bump {
+		db.bumpThread(post.Thread(), time.Now().Unix())
+	}
+	if rebuild {
+		s.rebuildThread(db, post)
+	}
+}
+
+// dismissReports deletes the reports of a post without approving it. Posts
+// hidden after reaching the report threshold are displayed again. The IP
+// addresses of the specified reports are banned from reporting. Bans expire
+// after the specified number of seconds, or never when zero.
+func (s *Server) dismissReports(db *Database, a *Account, post *Post, block []int, expire int64) {
+	reports := db.postReports(post)
+	if len(reports) == 0 {
+		return
+	}
+	for _, report := range reports {
+		var found bool
+		for _, id := range block {
+			if id == report.ID {
+				found = true
+				break
+			}
+		}
+		if !found || db.banByIP(report.IP) != nil {
+			continue
+		}
+		ban := &Ban{
+			IP:     report.IP,
+			Type:   BanReport,
+			Reason: "Report abuse",
+		}
+		if expire > 0 {
+			ban.Expire = time.Now().Unix() + expire
+		}
+		db.addBan(ban)
+
+		db.log(a, nil, fmt.Sprintf("Added >>/ban/%d", ban.ID), ban.Info())
+	}
+
+	db.deleteReports(post)
+
+	db.log(a, post.Board, fmt.Sprintf("Dismissed reports of >>/post/%d", post.ID), fmt.Sprintf("%d reports", len(reports)))
+
+	if post.ReportHidden {
+		db.moderatePost(post.ID, ModeratedVisible)
+		post.Moderated = ModeratedVisible
+		post.ReportHidden = false
+		s.rebuildThread(db, post)
+	}
 }
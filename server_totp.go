@@ -0,0 +1,130 @@
+package sriracha
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Time-based one-time password (RFC 6238) parameters. These match the defaults
+// of common authenticator apps.
+const (
+	totpPeriod        = 30
+	totpDigits        = 6
+	totpSkew          = 1
+	recoveryCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newTOTPSecret returns a new base32 encoded TOTP secret.
+func newTOTPSecret() string {
+	buf := make([]byte, 20)
+	_, err := rand.Read(buf)
+	if err != nil {
+		panic(err)
+	}
+	return totpEncoding.EncodeToString(buf)
+}
+
+// totpCode returns the TOTP code of a secret at the specified time step.
+func totpCode(secret string, counter int64) (string, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", false
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(buf)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), true
+}
+
+// verifyTOTP returns whether a code is valid for a secret at the specified
+// time, along with the time step of the code. Codes of adjacent time steps are
+// accepted to allow for clock drift.
+func verifyTOTP(secret string, code string, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	counter := now.Unix() / totpPeriod
+	for i := int64(-totpSkew); i <= totpSkew; i++ {
+		expected, ok := totpCode(secret, counter+i)
+		if ok && subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter + i, true
+		}
+	}
+	return 0, false
+}
+
+// totpURI returns a URI which may be entered into an authenticator app.
+func totpURI(issuer string, username string, secret string) string {
+	if issuer == "" {
+		issuer = "Sriracha"
+	}
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", issuer)
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+username) + "?" + values.Encode()
+}
+
+// newRecoveryCodes returns a set of single-use recovery codes and their
+// hashes, which are stored in place of the codes.
+func newRecoveryCodes() ([]string, string) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	buf := make([]byte, 5)
+	for i := range codes {
+		_, err := rand.Read(buf)
+		if err != nil {
+			panic(err)
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(buf))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashRecoveryCode(code)
+	}
+	return codes, strings.Join(hashes, ",")
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code) + srirachaServer.config.SaltPass))
+	return hex.EncodeToString(sum[:])
+}
+
+// useRecoveryCode returns whether a code matches one of the recovery code
+// hashes, along with the remaining hashes.
+func useRecoveryCode(hashes string, code string) (bool, string) {
+	if hashes == "" || normalizeRecoveryCode(code) == "" {
+		return false, hashes
+	}
+	hash := hashRecoveryCode(code)
+	remaining := strings.Split(hashes, ",")
+	for i, h := range remaining {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			remaining = append(remaining[:i], remaining[i+1:]...)
+			return true, strings.Join(remaining, ",")
+		}
+	}
+	return false, hashes
+}
@@ -1,6 +1,7 @@
 package sriracha
 
 import (
+	"crypto/subtle"
 	"embed"
 	"fmt"
 	"html/template"
@@ -17,22 +18,36 @@ import (
 var templateFS embed.FS
 
 type manageData struct {
-	Account  *Account
-	Accounts []*Account
-	Ban      *Ban
-	Bans     []*Ban
-	Board    *Board
-	Boards   []*Board
-	Keyword  *Keyword
-	Keywords []*Keyword
-	Log      *Log
-	Logs     []*Log
-	News     *News
-	AllNews  []*News
-	Plugin   *pluginInfo
-	Plugins  []*pluginInfo
-	Report   *Report
-	Reports  []*Report
+	Account        *Account
+	Accounts       []*Account
+	APIPermissions []Permission
+	APIToken       string
+	APITokens      []*APIToken
+	Appeal         *Appeal
+	Appeals        []*Appeal
+	Ban            *Ban
+	Bans           []*Ban
+	Board          *Board
+	Boards         []*Board
+	FloodAlerts    []*FloodAlert
+	Keyword        *Keyword
+	Keywords       []*Keyword
+	Log            *Log
+	LogActions     []*logAction
+	LogQuery       *logQuery
+	Logs           []*Log
+	News           *News
+	AllNews        []*News
+	Plugin         *pluginInfo
+	Plugins        []*pluginInfo
+	RecoveryCodes  []string
+	Report         *Report
+	Reports        []*Report
+	Role           *Role
+	Roles          []*Role
+	Sessions       []*Session
+	TOTPSecret     string
+	TOTPURI        string
 }
 
 type templateData struct {
@@ -55,6 +70,8 @@ type templateData struct {
 	Opt       *ServerOptions
 	Manage    *manageData
 	Template  string
+
+	loggedIn bool
 }
 
 func (data *templateData) BoardError(w http.ResponseWriter, message string) {
@@ -68,8 +85,8 @@ func (data *templateData) ManageError(message string) {
 	data.Info = message
 }
 
-func (data *templateData) forbidden(w http.ResponseWriter, required AccountRole) bool {
-	allow := data.Account != nil && data.Account.Role != 0 && data.Account.Role <= required
+func (data *templateData) forbidden(w http.ResponseWriter, required Permission) bool {
+	allow := data.Account != nil && data.Account.Can(required)
 	if allow {
 		return false
 	}
@@ -78,6 +95,29 @@ func (data *templateData) forbidden(w http.ResponseWriter, required AccountRole)
 	return true
 }
 
+// forbiddenBoard returns whether the account may not moderate the specified board.
+func (data *templateData) forbiddenBoard(w http.ResponseWriter, board *Board) bool {
+	if data.Account != nil && data.Account.Moderates(board) {
+		return false
+	}
+	data.Template = "manage_error"
+	data.Info = "Access forbidden."
+	return true
+}
+
+// CSRF returns the token which must be submitted with each management form.
+func (data *templateData) CSRF() string {
+	if data.Account == nil {
+		return ""
+	}
+	return data.Account.CSRF()
+}
+
+func (data *templateData) validCSRF(r *http.Request) bool {
+	token := data.CSRF()
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(r.FormValue("csrf"))) == 1
+}
+
 func (data *templateData) execute(w io.Writer) {
 	if data.Template == "" {
 		return
@@ -112,6 +152,15 @@ var templateFuncMap = template.FuncMap{
 	"HTML": func(text string) template.HTML {
 		return template.HTML(text)
 	},
+	"CSS": func(text string) template.CSS {
+		return template.CSS(text)
+	},
+	"CAPTCHA": func(b *Board) template.HTML {
+		return template.HTML(boardCAPTCHA(b).Provider.CAPTCHA(b))
+	},
+	"CAPTCHAProviders": func() []*captchaProviderInfo {
+		return allCAPTCHAProviders
+	},
 	"Iterate": func(i int) []int {
 		var values []int
 		for v := 0; v <= i; v++ {